@@ -0,0 +1,154 @@
+package chizap
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// WithStaticAggregation replaces the completion entries of all requests whose
+// path starts with one of the passed prefixes with a summary, logged once per
+// interval for each prefix that received requests.
+//
+// Each summary holds the following fields:
+//   - prefix: the path prefix
+//   - requests: the number of requests received during the interval
+//   - errors: the number of those requests that failed with a 5xx status
+//   - bytes_written: the total number of bytes written
+//   - avg_latency: the average latency of the requests
+//
+// This keeps static assets visible, without logging every single request.
+//
+// The summaries are logged by a goroutine that is started when the
+// middleware is created.
+// To log the summaries of the current interval when shutting down, register
+// the middleware with a [Closer] using [WithCloser].
+// Otherwise, the goroutine runs for the remainder of the program.
+func WithStaticAggregation(interval time.Duration, prefixes ...string) Option {
+	return func(c *config) {
+		c.aggInterval = interval
+		c.aggPrefixes = append(c.aggPrefixes, prefixes...)
+	}
+}
+
+type (
+	aggregator struct {
+		l        *zap.Logger
+		msg      string
+		prefixes []string
+
+		mu    sync.Mutex
+		stats map[string]*aggStats
+	}
+
+	aggStats struct {
+		requests int
+		errors   int
+		bytes    int
+		latency  time.Duration
+	}
+)
+
+func newAggregator(l *zap.Logger, msg string, interval time.Duration, prefixes []string, cl *Closer) *aggregator {
+	a := &aggregator{
+		l:        l,
+		msg:      msg,
+		prefixes: prefixes,
+		stats:    make(map[string]*aggStats, len(prefixes)),
+	}
+
+	flushPeriodically(l, interval, a.flush, cl)
+	return a
+}
+
+// match returns the first prefix of the aggregator path starts with.
+func (a *aggregator) match(path string) (string, bool) {
+	for _, prefix := range a.prefixes {
+		if strings.HasPrefix(path, prefix) {
+			return prefix, true
+		}
+	}
+
+	return "", false
+}
+
+func (a *aggregator) record(prefix string, status, bytes int, lat time.Duration) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	s, ok := a.stats[prefix]
+	if !ok {
+		s = new(aggStats)
+		a.stats[prefix] = s
+	}
+
+	s.requests++
+	if status >= http.StatusInternalServerError {
+		s.errors++
+	}
+	s.bytes += bytes
+	s.latency += lat
+}
+
+func (a *aggregator) flush() {
+	a.mu.Lock()
+	stats := a.stats
+	a.stats = make(map[string]*aggStats, len(a.prefixes))
+	a.mu.Unlock()
+
+	keys := make([]string, 0, len(stats))
+	for key := range stats {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		s := stats[key]
+		a.l.Info(a.msg,
+			zap.String("prefix", key),
+			zap.Int("requests", s.requests),
+			zap.Int("errors", s.errors),
+			zap.Int("bytes_written", s.bytes),
+			zap.Duration("avg_latency", s.latency/time.Duration(s.requests)),
+		)
+	}
+}
+
+// WithExcludedSummary logs a summary of the requests excluded from logging,
+// e.g. using [WithExcludedPaths] or [Healthz], once per interval, so that
+// a failing health check isn't completely invisible, just because its path
+// is excluded.
+//
+// A summary is logged for every excluded path prefix that received requests
+// during the interval, or, for requests excluded by their handler, for every
+// such path.
+// Each summary holds the same fields as those of [WithStaticAggregation].
+//
+// Requests logged because of [WithExcludedLevel] are not summarized.
+//
+// Like those of WithStaticAggregation, the summaries are logged by a
+// goroutine that is stopped, after logging the summaries of the current
+// interval, when the [Closer] passed to [WithCloser] is closed.
+func WithExcludedSummary(interval time.Duration) Option {
+	return func(c *config) {
+		c.excludedSummaryInterval = interval
+	}
+}
+
+// excludedKey returns the excluded prefix that path starts with, or, if
+// there is none, path itself.
+func (c *config) excludedKey(path string, dyn *Config) string {
+	for _, prefixes := range [][]string{c.excludedPaths, dyn.ExcludedPaths} {
+		for _, prefix := range prefixes {
+			if strings.HasPrefix(path, prefix) {
+				return prefix
+			}
+		}
+	}
+
+	return path
+}
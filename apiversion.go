@@ -0,0 +1,85 @@
+package chizap
+
+import (
+	"mime"
+	"net/http"
+	"strings"
+)
+
+// WithAPIVersion adds an api_version field to the completion entry, holding
+// the API version returned by f.
+// If f returns an empty string, the field is omitted.
+//
+// [APIVersionFromPath] and [APIVersionFromAccept] may be used as f.
+func WithAPIVersion(f func(r *http.Request) string) Option {
+	return func(c *config) {
+		c.apiVersion = f
+	}
+}
+
+// APIVersionFromPath extracts the API version from the first segment of the
+// request path, if it is of the form v<number>.
+// For example, for the path /v2/users, it returns v2.
+func APIVersionFromPath(r *http.Request) string {
+	seg := strings.TrimPrefix(r.URL.Path, "/")
+	if i := strings.IndexByte(seg, '/'); i >= 0 {
+		seg = seg[:i]
+	}
+
+	if !isVersion(seg) {
+		return ""
+	}
+
+	return seg
+}
+
+// APIVersionFromAccept extracts the API version from a vendor media type in
+// the Accept header.
+//
+// The version is either taken from a segment of the vendor subtype of the
+// form v<number>, or from a version parameter.
+// For example, for both application/vnd.example.v2+json and
+// application/vnd.example+json; version=v2 it returns v2.
+func APIVersionFromAccept(r *http.Request) string {
+	for _, accept := range r.Header.Values("Accept") {
+		for _, mediaType := range strings.Split(accept, ",") {
+			mt, params, err := mime.ParseMediaType(mediaType)
+			if err != nil {
+				continue
+			}
+
+			_, subtype, _ := strings.Cut(mt, "/")
+			if !strings.HasPrefix(subtype, "vnd.") {
+				continue
+			}
+
+			if v := params["version"]; v != "" {
+				return v
+			}
+
+			subtype, _, _ = strings.Cut(subtype, "+")
+			for _, seg := range strings.Split(subtype, ".") {
+				if isVersion(seg) {
+					return seg
+				}
+			}
+		}
+	}
+
+	return ""
+}
+
+// isVersion reports whether s is of the form v<number>.
+func isVersion(s string) bool {
+	if len(s) < 2 || s[0] != 'v' {
+		return false
+	}
+
+	for _, c := range s[1:] {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,108 @@
+package chizap
+
+import (
+	"sync"
+	"sync/atomic"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// WithAsync writes completion entries asynchronously, using a queue holding
+// up to queueSize entries, so that a slow or blocked log sink never delays
+// responses.
+//
+// If the queue is full, the entry is dropped and onDrop, if not nil, is
+// called with the total number of entries dropped so far.
+// That number is also logged as the log_dropped field of all subsequent
+// entries, once it is greater than zero.
+//
+// The queue is drained by a single goroutine that is started when the
+// middleware is created.
+// To write the queued entries when shutting down, register the middleware
+// with a [Closer] using [WithCloser], and close it after the server was shut
+// down.
+// Otherwise, the goroutine runs for the remainder of the program, and queued
+// entries are lost when it exits.
+func WithAsync(queueSize int, onDrop func(dropped uint64)) Option {
+	return func(c *config) {
+		c.asyncQueueSize = queueSize
+		c.asyncOnDrop = onDrop
+	}
+}
+
+type (
+	asyncWriter struct {
+		l       *zap.Logger
+		dropped atomic.Uint64
+		onDrop  func(dropped uint64)
+		done    chan struct{}
+
+		// mu guards sending on queue, so that it isn't closed concurrently.
+		mu     sync.RWMutex
+		queue  chan asyncEntry
+		closed bool
+	}
+
+	asyncEntry struct {
+		ce     *zapcore.CheckedEntry
+		fields []zap.Field
+	}
+)
+
+func newAsyncWriter(l *zap.Logger, queueSize int, onDrop func(uint64), cl *Closer) *asyncWriter {
+	w := &asyncWriter{
+		l:      l,
+		onDrop: onDrop,
+		done:   make(chan struct{}),
+		queue:  make(chan asyncEntry, queueSize),
+	}
+	go w.run()
+	cl.add(w.close)
+	return w
+}
+
+func (w *asyncWriter) run() {
+	defer close(w.done)
+
+	for e := range w.queue {
+		e.ce.Write(e.fields...)
+	}
+}
+
+// close writes all queued entries and syncs the logger.
+// Entries passed to write afterwards are written synchronously.
+func (w *asyncWriter) close() error {
+	w.mu.Lock()
+	w.closed = true
+	close(w.queue)
+	w.mu.Unlock()
+
+	<-w.done
+	return w.l.Sync()
+}
+
+// write enqueues ce to be written with the passed fields, or drops it if the
+// queue is full.
+func (w *asyncWriter) write(ce *zapcore.CheckedEntry, fields []zap.Field) {
+	if dropped := w.dropped.Load(); dropped > 0 {
+		fields = append(fields, zap.Uint64("log_dropped", dropped))
+	}
+
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	if w.closed {
+		ce.Write(fields...)
+		return
+	}
+
+	select {
+	case w.queue <- asyncEntry{ce: ce, fields: fields}:
+	default:
+		dropped := w.dropped.Add(1)
+		if w.onDrop != nil {
+			w.onDrop(dropped)
+		}
+	}
+}
@@ -0,0 +1,112 @@
+package chizap
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"hash"
+	"io"
+	"net/http"
+)
+
+// bodyRecorder wraps a request body and records up to limit bytes of what is
+// read from it.
+type bodyRecorder struct {
+	io.ReadCloser
+	limit int
+
+	buf       bytes.Buffer
+	truncated bool
+}
+
+// recordBody replaces the body of r with a bodyRecorder recording up to limit
+// bytes, and returns that recorder.
+// If r has no body, it returns nil.
+func recordBody(r *http.Request, limit int) *bodyRecorder {
+	if r.Body == nil || r.Body == http.NoBody {
+		return nil
+	}
+
+	rec := &bodyRecorder{ReadCloser: r.Body, limit: limit}
+	r.Body = rec
+	return rec
+}
+
+func (rec *bodyRecorder) Read(p []byte) (int, error) {
+	n, err := rec.ReadCloser.Read(p)
+	if n > 0 {
+		if remaining := rec.limit - rec.buf.Len(); remaining >= n {
+			rec.buf.Write(p[:n])
+		} else {
+			if remaining > 0 {
+				rec.buf.Write(p[:remaining])
+			}
+			rec.truncated = true
+		}
+	}
+
+	return n, err
+}
+
+// bytes returns the recorded bytes.
+// It is safe to call on a nil recorder.
+func (rec *bodyRecorder) bytes() []byte {
+	if rec == nil {
+		return nil
+	}
+
+	return rec.buf.Bytes()
+}
+
+// bodyHasher wraps a request body and computes the SHA-256 of what is read
+// from it.
+type bodyHasher struct {
+	io.ReadCloser
+	h hash.Hash
+	n int64
+}
+
+// hashBody replaces the body of r with a bodyHasher, and returns that hasher.
+// If r has no body, it returns nil.
+func hashBody(r *http.Request) *bodyHasher {
+	if r.Body == nil || r.Body == http.NoBody {
+		return nil
+	}
+
+	h := &bodyHasher{ReadCloser: r.Body, h: sha256.New()}
+	r.Body = h
+	return h
+}
+
+func (h *bodyHasher) Read(p []byte) (int, error) {
+	n, err := h.ReadCloser.Read(p)
+	h.h.Write(p[:n])
+	h.n += int64(n)
+	return n, err
+}
+
+// bodyTracker wraps a request body and counts the bytes read from it.
+type bodyTracker struct {
+	io.ReadCloser
+	read  bool
+	bytes int64
+}
+
+// trackBody replaces the body of r with a bodyTracker, and returns that
+// tracker.
+// If r has no body, it returns nil.
+func trackBody(r *http.Request) *bodyTracker {
+	if r.Body == nil || r.Body == http.NoBody {
+		return nil
+	}
+
+	t := &bodyTracker{ReadCloser: r.Body}
+	r.Body = t
+	return t
+}
+
+func (t *bodyTracker) Read(p []byte) (int, error) {
+	t.read = true
+	n, err := t.ReadCloser.Read(p)
+	t.bytes += int64(n)
+	return n, err
+}
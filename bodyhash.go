@@ -0,0 +1,40 @@
+package chizap
+
+import (
+	"encoding/hex"
+	"net/http"
+
+	"go.uber.org/zap"
+)
+
+// WithBodyHash adds the following fields to the completion entries of
+// POST, PUT, PATCH, and DELETE requests with a body:
+//   - body_sha256: the hex-encoded SHA-256 of the request body
+//   - body_hashed_bytes: the number of bytes that were hashed
+//
+// The hash is computed while the handler reads the body, without buffering
+// it.
+// Hence, if the handler doesn't read the body completely, only the part that
+// was read is hashed, which is reflected by body_hashed_bytes.
+func WithBodyHash() Option {
+	return func(c *config) {
+		c.bodyHash = true
+	}
+}
+
+// isMutating reports whether r uses a method that mutates resources.
+func isMutating(r *http.Request) bool {
+	switch r.Method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		return true
+	default:
+		return false
+	}
+}
+
+func appendBodyHashFields(fields []zap.Field, h *bodyHasher) []zap.Field {
+	return append(fields,
+		zap.String("body_sha256", hex.EncodeToString(h.h.Sum(nil))),
+		zap.Int64("body_hashed_bytes", h.n),
+	)
+}
@@ -0,0 +1,138 @@
+package chizap
+
+import (
+	"net/http"
+	"strings"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// CDN describes the headers a CDN adds to the requests it forwards.
+type CDN struct {
+	// RequestIDHeader is the header holding the ID the CDN assigned to the
+	// request.
+	RequestIDHeader string
+	// ClientIPHeader is the header holding the IP of the client.
+	ClientIPHeader string
+	// CountryHeader is the header holding the country of the client.
+	CountryHeader string
+	// CountryKey, if set, is the key of the country in CountryHeader, if the
+	// header is a comma-separated list of key=value pairs.
+	CountryKey string
+}
+
+var (
+	// Cloudflare is the [CDN] preset for Cloudflare.
+	Cloudflare = CDN{
+		RequestIDHeader: "CF-Ray",
+		ClientIPHeader:  "CF-Connecting-IP",
+		CountryHeader:   "CF-IPCountry",
+	}
+	// Fastly is the [CDN] preset for Fastly.
+	//
+	// Fastly doesn't add the client's country by default, so it expects it
+	// in the Fastly-Geo-Country header, which must be set using VCL.
+	Fastly = CDN{
+		RequestIDHeader: "X-Fastly-Request-ID",
+		ClientIPHeader:  "Fastly-Client-IP",
+		CountryHeader:   "Fastly-Geo-Country",
+	}
+	// Akamai is the [CDN] preset for Akamai.
+	Akamai = CDN{
+		RequestIDHeader: "X-Akamai-Request-ID",
+		ClientIPHeader:  "True-Client-IP",
+		CountryHeader:   "X-Akamai-Edgescape",
+		CountryKey:      "country_code",
+	}
+)
+
+// WithCDN adds the following fields, extracted from the headers of the passed
+// [CDN], to both the logger saved in the request context and the completion
+// entry:
+//   - edge_request_id: the ID the CDN assigned to the request
+//   - client_ip: the IP of the client
+//   - geo: an object holding the country of the client in its country field
+//
+// Fields whose headers aren't set are omitted.
+// If used together with [WithProxyHeaders], the client IP reported by the CDN
+// takes precedence.
+func WithCDN(cdn CDN) Option {
+	return func(c *config) {
+		c.cdn = &cdn
+	}
+}
+
+// WithLBRequestID adds an lb_request_id field to both the logger saved in the
+// request context and the completion entry, holding the value of the first
+// of the passed request headers that is set.
+// This allows joining server logs with the access logs of the load balancer.
+//
+// If no headers are passed, the following headers are used:
+//   - X-Cloud-Trace-Context: set by Google Cloud load balancers
+//   - X-Request-ID: set by Heroku's router
+//   - X-Azure-Ref: set by Azure Front Door
+//
+// If none of the headers is set, the field is omitted.
+func WithLBRequestID(headers ...string) Option {
+	if len(headers) == 0 {
+		headers = []string{"X-Cloud-Trace-Context", "X-Request-ID", "X-Azure-Ref"}
+	}
+
+	return func(c *config) {
+		c.lbRequestIDHeaders = headers
+	}
+}
+
+// appendCDNFields appends the CDN fields and reports whether it appended a
+// client_ip field.
+func appendCDNFields(fields []zap.Field, r *http.Request, cdn *CDN, clean func(string) string) ([]zap.Field, bool) {
+	if id := headerValue(r.Header, cdn.RequestIDHeader); id != "" {
+		fields = append(fields, zap.String("edge_request_id", clean(id)))
+	}
+
+	ip := headerValue(r.Header, cdn.ClientIPHeader)
+	if ip != "" {
+		fields = append(fields, zap.String("client_ip", clean(ip)))
+	}
+
+	country := headerValue(r.Header, cdn.CountryHeader)
+	if cdn.CountryKey != "" {
+		country = listValue(country, cdn.CountryKey)
+	}
+	if country != "" {
+		fields = append(fields, zap.Object("geo", geoObject{country: clean(country)}))
+	}
+
+	return fields, ip != ""
+}
+
+type geoObject struct {
+	country string
+}
+
+func (o geoObject) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	enc.AddString("country", o.country)
+	return nil
+}
+
+func headerValue(h http.Header, key string) string {
+	if key == "" {
+		return ""
+	}
+
+	return h.Get(key)
+}
+
+// listValue returns the value of key in the passed comma-separated list of
+// key=value pairs.
+func listValue(list, key string) string {
+	for _, pair := range strings.Split(list, ",") {
+		k, v, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		if ok && k == key {
+			return v
+		}
+	}
+
+	return ""
+}
@@ -26,27 +26,47 @@ SOFTWARE.
 */
 
 // Package chizap provides a logging and recovery middleware for chi using zap.
+//
+// Although built for chi, the middleware is a plain net/http middleware, and
+// can be used with any router.
+// Only route patterns are router-specific, and are obtained through the
+// [RouteFunc] set using [WithRoutes].
 package chizap
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"io"
 	"net"
 	"net/http"
 	"net/http/httputil"
 	"os"
 	"runtime/debug"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/go-chi/chi/v5/middleware"
 	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
 )
 
-type ctxKey struct{}
-
 // Logger returns a middleware handler that logs all requests using the passed
 // [zap.Logger].
 //
+// It is shorthand for:
+//
+//	New(l, WithExcludedPaths(excludedPaths...))
+//
+// Refer to [New] for more information.
+func Logger(l *zap.Logger, excludedPaths ...string) func(http.Handler) http.Handler {
+	return New(l, WithExcludedPaths(excludedPaths...))
+}
+
+// New returns a middleware handler that logs all requests using the passed
+// [zap.Logger], configured using the passed [Option]s.
+//
 // Additionally, it saves a logger instance in the request context, to be
 // retrieved using [Get].
 // Besides the fields already added to the logger, that instance also holds
@@ -56,64 +76,671 @@ type ctxKey struct{}
 //   - proto: the request protocol
 //   - method: the HTTP method of the request
 //   - path: the path of the request
-//   - query: the query string of the request
+//   - query: the query string of the request, replaced by query_params, if
+//     [WithQueryParams] is used
 //   - remote: the remote address of the client
 //   - user_agent: the user agent of the client
 //   - referer: the referer of the client
+//   - request_form: absolute or authority, if the request target is in
+//     absolute form, e.g. when sent to a forward proxy, or authority form,
+//     as used by CONNECT, omitted for the usual origin form
+//   - target_host: the host of the request target, if request_form is set
+//   - remote_ip: the IP of the remote address, if it has one
+//   - remote_port: the port of the remote address, if it has one
 //
-// If you don't want a certain path prefix to be logged, you may specify it as
-// one of the excludedPaths.
-// Even if a path prefix is echoed, the logger will still be saved in the
-// request context.
-func Logger(l *zap.Logger, excludedPaths ...string) func(http.Handler) http.Handler {
+// The first eight of these fields can be removed using [WithoutFields].
+//
+// Once the request completes, an entry is logged containing the above fields
+// and the following ones:
+//   - route: the route pattern that matched the request, if any, as
+//     determined by the [RouteFunc] set using [WithRoutes], by default the
+//     chi route pattern, or, when used with an [http.ServeMux] and built with
+//     Go 1.23 or later, the matched ServeMux pattern without its method
+//   - status: the status code of the response, which is 200, if the
+//     handler didn't write one, or 0, if the response was aborted
+//   - bytes_written: the number of bytes of the response body sent to the
+//     client; if the handler wrote a body in response to a HEAD request,
+//     which net/http discards, this is 0, and body_suppressed is added and
+//     set to true
+//   - latency: the time it took to handle the request, encoded as
+//     configured using [WithLatencyUnit]
+//   - in_flight: the number of requests being handled by the middleware,
+//     including this one, at the time the request arrived
+//   - seq: the sequence number of the request, starting at 1 and incremented
+//     for every request handled by the middleware
+//   - status_class: the class of the status code, e.g. 2xx or 4xx
+//   - outcome: success, if the status code is below 400, client_error, if
+//     it is a 4xx status, server_error, if it is a 5xx status, timeout, if
+//     the request context exceeded its deadline (see [Timeout]), and panic
+//     if the handler panicked
+//   - panicked: true, if the handler panicked, otherwise omitted; see
+//     [Recoverer]
+//   - aborted: true, if the handler panicked and no [Recoverer] is mounted
+//     before the middleware, so that net/http aborted the response instead
+//     of sending one, otherwise omitted
+//   - warnings: the warnings buffered using [Warnf], if any
+//   - error: the error attached using [Error], if any, or errors, if
+//     multiple errors were attached
+//   - error_details: the first error attached using [Error] that implements
+//     [zapcore.ObjectMarshaler], logged structurally, if any
+//
+// Additional fields may be added through options.
+//
+// If l is nil, New falls back to a no-op logger.
+// Invalid arguments, such as a nil logger or nil options, are reported once,
+// when New is called, using l, or, if l is nil or disabled, by writing to
+// stderr.
+func New(l *zap.Logger, opts ...Option) func(http.Handler) http.Handler {
+	l, opts, err := validateArgs(l, opts)
+
+	var c config
+	for _, opt := range opts {
+		opt(&c)
+	}
+
+	if err = errors.Join(err, c.validate()); err != nil {
+		reportInvalid(l, err)
+	}
+
+	if !c.sanitizerSet {
+		c.sanitize = EscapeControlChars
+	}
+
+	var inFlight, seq atomic.Int64
+
+	diag := &diagnostics{l: l, strict: c.strictOrdering}
+
+	var dedup *deduper
+	if c.dedupWindow > 0 {
+		dedup = newDeduper(l, c.dedupWindow)
+	}
+
+	var async *asyncWriter
+	if c.asyncQueueSize > 0 {
+		async = newAsyncWriter(l, c.asyncQueueSize, c.asyncOnDrop, c.closer)
+	}
+
+	var agg *aggregator
+	if c.aggInterval > 0 && len(c.aggPrefixes) > 0 {
+		agg = newAggregator(l, "static requests summary", c.aggInterval, c.aggPrefixes, c.closer)
+	}
+
+	for _, sink := range c.recordSinks {
+		c.observers = append(c.observers, &sinkObserver{l: l, sink: sink})
+	}
+
+	if c.accessSummaryInterval > 0 {
+		c.observers = append(c.observers, newAccessSummary(l, c.accessSummaryInterval, c.closer))
+	}
+
+	var excludedAgg *aggregator
+	if c.excludedSummaryInterval > 0 {
+		excludedAgg = newAggregator(l, "excluded requests summary", c.excludedSummaryInterval, nil, c.closer)
+	}
+
 	return func(next http.Handler) http.Handler {
+		if c.trace {
+			next = c.traceTask(next)
+		}
+		if c.pprofLabels {
+			next = c.labelProfiles(next)
+		}
+
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			var excluded bool
-			for _, path := range excludedPaths {
-				if strings.HasPrefix(r.URL.Path, path) {
-					excluded = true
-					break
-				}
-			}
+			curInFlight := inFlight.Add(1)
+			defer inFlight.Add(-1)
+			curSeq := seq.Add(1)
+
+			dyn := c.loadDynamic()
+			excluded := c.isExcluded(r) || dyn.isExcluded(r.URL.Path)
+			logExcluded := c.excludedLevel != nil && l.Core().Enabled(*c.excludedLevel)
 
 			var start time.Time
-			if !excluded {
+			if !excluded || logExcluded || excludedAgg != nil {
 				start = time.Now()
 			}
 
-			rl := l.With(
-				zap.String("request_id", middleware.GetReqID(r.Context())),
-				zap.String("proto", r.Proto),
-				zap.String("method", r.Method),
-				zap.String("path", r.URL.Path),
-				zap.String("query", r.URL.RawQuery),
-				zap.String("remote", r.RemoteAddr),
-				zap.String("user_agent", r.UserAgent()),
-				zap.String("referer", r.Referer()),
-			)
-			set(r, rl)
+			diag.checkRecoverer(r)
+			// The context fields are built lazily, so they are built from a
+			// copy of the request as it is now, before the handler or
+			// succeeding middlewares, e.g. RealIP, modify it in place.
+			var snap http.Request
+			s := newState(r, l, &c, diag, func() []zap.Field { return c.contextFields(&snap) })
+			markState(r, s)
+			if !c.noContextLogger {
+				s.attach(r)
+			}
+			snap = *r
+
+			var replayBody *bodyRecorder
+			replay := c.replayLogger != nil && c.replayCapture(r)
+			if replay {
+				replayBody = recordBody(r, c.replayMaxBody)
+			}
+			if c.reproCurl {
+				s.reproBody = recordBody(r, c.reproMaxBody)
+			}
+			if c.panicBody > 0 {
+				s.panicBody = recordBody(r, c.panicBody)
+			}
+			if c.bodyHash && isMutating(r) {
+				s.bodyHash = hashBody(r)
+			}
+			if c.expectContinue && expectsContinue(r) {
+				if s.expectContinue = trackBody(r); s.expectContinue == nil {
+					s.expectContinue = new(bodyTracker)
+				}
+			}
 
 			ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
-			next.ServeHTTP(ww, r)
 
-			if !excluded {
-				lat := time.Since(start)
-				rl.Info(r.Method+" "+r.URL.Path,
-					zap.Int("status", ww.Status()),
-					zap.Int("bytes_written", ww.BytesWritten()),
-					zap.Duration("latency", lat),
-				)
+			var har *harCapture
+			if c.har != nil && c.har.sample(c.harSampleRate) {
+				har = c.har.capture(r, ww)
+			}
+
+			var rw middleware.WrapResponseWriter = ww
+			if c.tunnels && r.Method == http.MethodConnect {
+				rw = preserveCapabilities(&tunnelWriter{WrapResponseWriter: rw, s: s, r: r}, rw)
+			}
+			if c.logIDHeader != "" {
+				lw := &logIDWriter{WrapResponseWriter: rw, r: r, name: c.logIDHeader, value: c.logIDValue}
+				rw = preserveCapabilities(lw, rw)
+			}
+
+			rec, stack := serve(next, rw, r)
+			if rec != nil {
+				// Log the completion entry first, and then leave the panic to
+				// the Recoverer mounted before the middleware, or net/http.
+				defer panic(rec)
+
+				if rec != http.ErrAbortHandler { //nolint:errorlint // compared by identity, like net/http does
+					s.panicked = true
+					s.panicValue = rec
+					s.panicStack = stack
+				}
+			}
+
+			if isDeadlineExceeded(r) {
+				s.timedOut = true
+			}
+
+			attachedErr := s.attachedError()
+			if rec == nil && attachedErr != nil && !headerWritten(ww) {
+				if status := errorStatus(attachedErr); status != 0 {
+					rw.WriteHeader(status)
+				}
+			}
+
+			if replay {
+				logReplay(c.replayLogger, r, replayBody, c.maxFieldLength)
+			}
+			if har != nil {
+				c.har.record(har, r, ww)
+			}
+
+			// Without a Recoverer mounted before the middleware, net/http
+			// aborts the response of a panicking handler instead of sending
+			// a status.
+			aborted := rec != nil && markedState(r) == nil
+
+			unwritten := http.StatusOK
+			switch {
+			case aborted:
+				unwritten = 0
+			case rec != nil:
+				unwritten = c.panicStatusCode()
+			}
+			ww, bodySuppressed := accountResponse(r, ww, unwritten)
+
+			// In single-entry mode, the completion entry is the only entry
+			// logged for a panic, so it must not be dropped.
+			merged := c.singleEntryPanics && s.panicked
+
+			if !merged && c.isExcludedContentType(ww.Header()) {
+				return
+			}
+
+			excluded = !merged && (excluded || s.excluded)
+			if excluded && !logExcluded {
+				if excludedAgg != nil {
+					excludedAgg.record(c.excludedKey(r.URL.Path, dyn), ww.Status(), ww.BytesWritten(), time.Since(start))
+				}
+				return
+			}
+
+			if agg != nil && !excluded && !merged {
+				if prefix, ok := agg.match(r.URL.Path); ok {
+					agg.record(prefix, ww.Status(), ww.BytesWritten(), time.Since(start))
+					return
+				}
+			}
+
+			if c.preflight != PreflightLog && isPreflight(r) && !merged {
+				if c.preflight == PreflightDebug {
+					l.Debug(r.Method+" "+c.clean(r.URL.Path),
+						zap.String("request_id", c.cleanField(middleware.GetReqID(r.Context()))),
+						zap.String("path", c.clean(r.URL.Path)),
+						zap.String("origin", c.clean(r.Header.Get("Origin"))),
+						zap.Int("status", ww.Status()),
+						zap.Bool("cors_preflight", true),
+					)
+				}
+				return
+			}
+
+			if len(c.ipObservers) > 0 && !excluded {
+				ip := c.clientIP(r)
+				for _, o := range c.ipObservers {
+					o.Observe(ip, ww.Status(), r.URL.Path)
+				}
+			}
+
+			lat := time.Since(start)
+			route := c.routePattern(r)
+
+			if len(c.observers) > 0 && !excluded {
+				completion := Completion{
+					Request:      r,
+					Start:        start,
+					Route:        route,
+					Status:       ww.Status(),
+					BytesWritten: ww.BytesWritten(),
+					Latency:      lat,
+					Panicked:     s.panicked,
+					Err:          attachedErr,
+				}
+				for _, o := range c.observers {
+					o.ObserveCompletion(completion)
+				}
+			}
+
+			unmatched := c.unmatchedLevel != nil && isUnmatched(route, ww.Status())
+
+			lvl := zapcore.InfoLevel
+			switch {
+			case merged:
+				lvl = zapcore.ErrorLevel
+			case excluded:
+				lvl = *c.excludedLevel
+			case unmatched:
+				lvl = *c.unmatchedLevel
+			case c.level != nil:
+				lvl = c.level(ww.Status())
+			}
+
+			slow := !excluded && dyn.isSlow(lat)
+			if slow && lvl < zapcore.WarnLevel {
+				lvl = zapcore.WarnLevel
+			}
+
+			// The logger the completion entry is written to.
+			cl := l
+			shadow := c.shadowHeader != "" && r.Header.Get(c.shadowHeader) != ""
+			if shadow && c.shadowLogger != nil {
+				cl = c.shadowLogger
+			}
+
+			// Completion hooks are called even if the entry isn't written.
+			hooked := len(c.completionHooks) > 0
+			write := cl.Core().Enabled(lvl) && (lvl >= zapcore.WarnLevel || !c.sampledOut(r, dyn))
+			// Don't bother building any fields, if we won't use them anyway.
+			if !write && !hooked {
+				return
+			}
+
+			msgPath := messagePath(r)
+
+			if c.pathTemplating {
+				if route == "" {
+					route = templatePath(r.URL.Path)
+				}
+				msgPath = route
+			}
+
+			msg := r.Method + " " + c.clean(msgPath)
+
+			if write && dedup != nil && lvl >= zapcore.ErrorLevel {
+				key := dedupKey{route: route, status: ww.Status()}
+				if key.route == "" {
+					key.route = r.URL.Path
+				}
+				if attachedErr != nil {
+					key.err = attachedErr.Error()
+				}
+				if dedup.suppress(msg, lvl, key) {
+					if !hooked {
+						return
+					}
+					write = false
+				}
+			}
+
+			var ce *zapcore.CheckedEntry
+			var fields []zap.Field
+			// The context logger is derived from l, so if the entry goes to
+			// another logger, the context fields must be added manually.
+			ownContextFields := c.noContextLogger || cl != l
+			if ownContextFields {
+				if write {
+					ce = cl.Check(lvl, msg)
+				}
+				fields = s.contextFields()
+			} else if write {
+				ce = s.logger().Check(lvl, msg)
+			}
+
+			if ce == nil && !hooked {
+				return
+			}
+
+			fields = append(fields,
+				zap.String("route", c.clean(route)),
+				zap.Int("status", ww.Status()),
+				zap.Int("bytes_written", ww.BytesWritten()),
+				latencyField(lat, c.latencyUnit),
+				zap.Int64("in_flight", curInFlight),
+				zap.Int64("seq", curSeq),
+				zap.String("status_class", statusClass(ww.Status())),
+				zap.String("outcome", outcome(ww.Status(), s)),
+			)
+
+			if slow {
+				fields = append(fields, zap.Bool("slow", true))
+			}
+			if unmatched {
+				fields = append(fields, zap.Bool("route_matched", false))
+			}
+			if shadow {
+				fields = append(fields, zap.Bool("shadow", true))
+			}
+			if bodySuppressed {
+				fields = append(fields, zap.Bool("body_suppressed", true))
+			}
+			if s.panicked {
+				fields = append(fields, zap.Bool("panicked", true))
+			}
+			if aborted {
+				fields = append(fields, zap.Bool("aborted", true))
+			}
+			if merged {
+				fields = append(fields, zap.Any("panic", s.panicValue))
+				if s.panicStack != nil {
+					fields = append(fields, zap.ByteString("stack", s.panicStack))
+				}
+				if s.panicBody != nil {
+					body, truncated := truncateBytes(s.panicBody.bytes(), c.maxFieldLength)
+					fields = append(fields,
+						zap.ByteString("request_body", body),
+						zap.Bool("body_truncated", truncated || s.panicBody.truncated))
+				}
+			}
+			if s.tunnel != nil {
+				fields = append(fields, zap.Bool("tunnel_established", true))
+			}
+
+			if c.startTime {
+				fields = append(fields, zap.String("start_time", start.Format(time.RFC3339Nano)))
+			}
+			if c.latencyUnit == DualLatency {
+				fields = append(fields, zap.Int64("latency_ns", lat.Nanoseconds()))
+			}
+			if c.humanLatency {
+				fields = append(fields, zap.String("latency_human", lat.String()))
+			}
+
+			fields = c.appendHandlerField(fields, r, s, route)
+			fields = c.appendCompletionFields(fields, r, ww, s, lat)
+
+			entry := zapcore.Entry{Level: lvl, Time: time.Now(), Message: msg}
+			if ce != nil {
+				entry = ce.Entry
+			}
+
+			switch {
+			case ce == nil:
+			case async != nil:
+				async.write(ce, fields)
+			default:
+				ce.Write(fields...)
+			}
+
+			if hooked {
+				if !ownContextFields {
+					s.initLogger()
+					fields = append(s.fields[:len(s.fields):len(s.fields)], fields...)
+				}
+
+				for _, h := range c.completionHooks {
+					h(r, entry, fields)
+				}
 			}
 		})
 	}
 }
 
+// contextFields returns the fields of the logger saved in the request
+// context.
+func (c *config) contextFields(r *http.Request) []zap.Field {
+	fields := make([]zap.Field, 0, 16)
+	if c.has(FieldRequestID) {
+		fields = append(fields, zap.String("request_id", c.cleanField(middleware.GetReqID(r.Context()))))
+	}
+	if c.has(FieldProto) {
+		fields = append(fields, zap.String("proto", r.Proto))
+	}
+	if c.has(FieldMethod) {
+		fields = append(fields, zap.String("method", r.Method))
+	}
+	if c.has(FieldPath) {
+		fields = append(fields, zap.String("path", c.clean(r.URL.Path)))
+	}
+	if c.has(FieldQuery) {
+		fields = append(fields, queryField(r, c.queryParams, c.cleanField))
+	}
+	if c.has(FieldRemote) {
+		fields = append(fields, zap.String("remote", c.clean(r.RemoteAddr)))
+	}
+	if c.has(FieldUserAgent) {
+		fields = append(fields, zap.String("user_agent", c.cleanField(r.UserAgent())))
+	}
+	if c.has(FieldReferer) {
+		fields = append(fields, zap.String("referer", c.cleanField(r.Referer())))
+	}
+
+	fields = appendTargetFields(fields, r, c.clean)
+	fields = appendRemoteFields(fields, r.RemoteAddr)
+
+	var haveClientIP bool
+	if c.cdn != nil {
+		fields, haveClientIP = appendCDNFields(fields, r, c.cdn, c.cleanField)
+	}
+	if c.proxyHeaders {
+		fields, haveClientIP = appendProxyFields(fields, r, haveClientIP, c.cleanField)
+	}
+	fields = appendPeerFields(fields, r, haveClientIP)
+
+	for _, h := range c.lbRequestIDHeaders {
+		if id := r.Header.Get(h); id != "" {
+			fields = append(fields, zap.String("lb_request_id", c.cleanField(id)))
+			break
+		}
+	}
+	if c.proxyChain {
+		fields = appendProxyChainFields(fields, r, c.cleanField)
+	}
+
+	if c.tenant != nil {
+		if tenant := c.tenant(r); tenant != "" {
+			fields = append(fields, zap.String("tenant_id", c.cleanField(tenant)))
+		}
+	}
+
+	if c.sessionHash {
+		if cred := sessionCredential(r, c.sessionCookies); cred != "" {
+			fields = append(fields, zap.String("session_hash", hmacString(c.sessionSalt, cred)))
+		}
+	}
+
+	if c.connInfo {
+		fields = appendConnFields(fields, r)
+	}
+
+	if len(c.headers) > 0 {
+		fields = append(fields, headerField("headers", r.Header, c.headers, c.cleanField))
+	}
+
+	if len(c.jwtClaims) > 0 {
+		fields = appendJWTFields(fields, r, c.jwtClaims)
+	}
+
+	if len(c.contextValues) > 0 {
+		fields = appendContextValueFields(fields, r, c.contextValues)
+	}
+
+	return fields
+}
+
+// appendCompletionFields appends the optional fields of the completion entry
+// to fields.
+func (c *config) appendCompletionFields(
+	fields []zap.Field, r *http.Request, ww middleware.WrapResponseWriter, s *state, lat time.Duration,
+) []zap.Field {
+	if c.throughput && ww.BytesWritten() >= c.throughputMinBytes && lat > 0 {
+		fields = append(fields, zap.Float64("throughput_bps", float64(ww.BytesWritten())/lat.Seconds()))
+	}
+
+	if s.measuredUncompressed {
+		fields = append(fields, zap.Int("bytes_uncompressed", s.uncompressed))
+		if ww.BytesWritten() > 0 {
+			fields = append(fields,
+				zap.Float64("compression_ratio", float64(s.uncompressed)/float64(ww.BytesWritten())))
+		}
+	}
+
+	if c.rangeFields {
+		fields = appendRangeFields(fields, r, ww, c.cleanField)
+	}
+
+	if c.redirect {
+		fields = appendRedirectField(fields, ww, c.cleanField)
+	}
+
+	if len(c.cacheStatusHeaders) > 0 {
+		fields = appendCacheStatusField(fields, c.cacheStatusHeaders, ww, c.cleanField)
+	}
+
+	if c.conditional {
+		fields = appendConditionalFields(fields, r, ww)
+	}
+
+	if c.rateLimit {
+		fields = appendRateLimitFields(fields, ww, c.cleanField)
+	}
+
+	if c.idempotencyKey {
+		if key := r.Header.Get("Idempotency-Key"); key != "" {
+			if c.hashIdempotencyKey {
+				key = hashString(key)
+			}
+			fields = append(fields, zap.String("idempotency_key", c.cleanField(key)))
+		}
+	}
+
+	if c.apiVersion != nil {
+		if v := c.apiVersion(r); v != "" {
+			fields = append(fields, zap.String("api_version", c.cleanField(v)))
+		}
+	}
+
+	if c.auth {
+		fields = append(fields, authField(r, c.authPrincipalType, c.cleanField))
+	}
+
+	fields = c.appendDeploymentSlotField(fields, r)
+
+	if c.featureFlags != nil {
+		fields = c.appendFeatureFlagsField(fields, r)
+	}
+	if c.experiment != nil {
+		fields = c.appendExperimentFields(fields, r)
+	}
+
+	if c.contentNegotiation {
+		fields = appendNegotiationFields(fields, r, ww, c.cleanField)
+	}
+
+	if c.suspicious {
+		fields = appendSuspiciousFields(fields, r, c.maxHeaderSize)
+	}
+
+	if c.locale {
+		if locale := primaryLocale(r); locale != "" {
+			fields = append(fields, zap.String("locale", c.cleanField(locale)))
+		}
+	}
+
+	if len(c.responseHeaders) > 0 {
+		fields = append(fields, headerField("response_headers", ww.Header(), c.responseHeaders, c.cleanField))
+	}
+
+	if c.trailers {
+		if trailers, keys := trailerHeader(ww.Header(), c.trailerNames); len(keys) > 0 {
+			fields = append(fields, headerField("trailers", trailers, keys, c.cleanField))
+		}
+	}
+
+	fields = s.appendErrorFields(fields)
+
+	if !s.queueStart.IsZero() {
+		fields = appendThrottleFields(fields, s, time.Now(), c.latencyUnit)
+	}
+
+	if s.timedOut && s.timeout > 0 {
+		fields = append(fields, zap.Duration("timeout", s.timeout))
+	}
+
+	fields = s.appendValueFields(fields)
+	fields = s.appendWarningsField(fields)
+
+	if s.bodyHash != nil {
+		fields = appendBodyHashFields(fields, s.bodyHash)
+	}
+
+	if s.expectContinue != nil {
+		fields = appendExpectContinueFields(fields, s.expectContinue)
+	}
+
+	if c.reproCurl && (s.panicked || ww.Status() >= http.StatusInternalServerError) {
+		cmd := curlCommand(r, s.reproBody.bytes(), c.reproRedacted, c.reproRedactedQuery, c.reproMaxBody)
+		fields = append(fields, zap.String("repro_curl", c.cleanField(cmd)))
+	}
+
+	return append(fields, c.staticFields...)
+}
+
 // Get returns the [*zap.Logger] instance saved in the request context by the
 // [Logger] middleware.
 //
 // Must be called after the [Logger] middleware.
 func Get(r *http.Request) *zap.Logger {
-	return r.Context().Value(ctxKey{}).(*zap.Logger)
+	s := r.Context().Value(stateKey{}).(*state)
+	s.diag.checkRequestID(s, r)
+	return s.logger()
+}
+
+// FromContext returns the [*zap.Logger] instance saved in ctx by the
+// [Logger] middleware, e.g. in the context of a request, or one obtained
+// using [Detach].
+//
+// If ctx doesn't stem from a request handled by the [Logger] middleware,
+// FromContext returns the global logger, as returned by [zap.L].
+func FromContext(ctx context.Context) *zap.Logger {
+	if s, ok := ctx.Value(stateKey{}).(*state); ok {
+		return s.logger()
+	}
+
+	return zap.L()
 }
 
 // GetSugared is shorthand for:
@@ -123,20 +750,42 @@ func GetSugared(r *http.Request) *zap.SugaredLogger {
 	return Get(r).Sugar()
 }
 
-func set(r *http.Request, l *zap.Logger) {
-	*r = *r.WithContext(context.WithValue(r.Context(), ctxKey{}, l))
-}
-
 // Recoverer recovers from panics and logs the stack trace using the logger
 // added by [Logger].
+//
+// After recovering, it responds with status 500, or the status and headers
+// configured using [WithPanicStatus] and [WithPanicHeader].
+// If the request has a request ID and the header wasn't written yet, the
+// request ID is also sent as the X-Request-Id header, so that users reporting
+// an error can be matched to the logged stack trace.
+//
+// It should be mounted after [Logger].
+// If it is mounted before, [Logger] logs the completion entry of a panicking
+// request, and then re-raises the panic, which Recoverer logs using the
+// request's logger, but only after the panic unwound through all middlewares
+// mounted in between.
+// [Logger] reports this misordering, as described in [WithStrictOrdering].
+//
+// Either way, the completion entry of a panicking request has its panicked
+// field set to true, and, unless a response was already written, holds the
+// status Recoverer responds with.
+// If no Recoverer is mounted at all, net/http aborts the response, which
+// [Logger] reports using the aborted field instead.
 func Recoverer(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r = markRecoverer(r)
+
 		defer func() {
 			rec := recover()
 			if rec == nil {
 				return
 			}
 
+			// The code below calls user code, e.g. field extractors and the
+			// logger's cores, so make sure a panic in there doesn't take down
+			// the whole process.
+			defer recoverRecovery(w, rec)
+
 			// Check for a broken connection, as it is not really a
 			// condition that warrants a panic stack trace.
 			var brokenPipe bool
@@ -150,26 +799,132 @@ func Recoverer(next http.Handler) http.Handler {
 				}
 			}
 
-			l := Get(r)
+			l := zap.L()
+			status := http.StatusInternalServerError
+			var header http.Header
+
+			reqID := middleware.GetReqID(r.Context())
+			// Make sure the request ID is logged, even if the logger doesn't
+			// have it.
+			logReqID := reqID != ""
+
+			s := getState(r)
+			if s != nil {
+				l = Get(r)
+			} else if s = markedState(r); s != nil {
+				// The panic was re-raised by a Logger mounted after us.
+				l = s.logger()
+			}
+
+			if s != nil {
+				s.panicked = true
+				s.panicValue = rec
+				logReqID = logReqID && s.noRequestID
+				status = s.cfg.panicStatusCode()
+				header = s.cfg.panicHeader
+			}
+
+			// In single-entry mode, the panic is logged as part of the
+			// completion entry instead.
+			single := s != nil && s.cfg.singleEntryPanics
 
-			httpRequest, _ := httputil.DumpRequest(r, false)
 			if brokenPipe {
-				l.Error(r.Method+" "+r.URL.Path,
-					zap.Any("error", rec),
-					zap.String("request", string(httpRequest)),
-				)
+				if !single {
+					l.Error(r.Method+" "+cleanPath(r), panicFields(r, s, rec, reqID, logReqID)...)
+				}
 				return
 			}
 
-			l.Error(r.Method+" "+r.URL.Path+" Recovered from panic",
-				zap.Any("error", rec),
-				zap.String("request", string(httpRequest)),
-				zap.String("stack", string(debug.Stack())),
-			)
+			stack := debug.Stack()
+			if s != nil && s.panicStack != nil {
+				stack = s.panicStack
+			}
 
-			w.WriteHeader(http.StatusInternalServerError)
+			if single {
+				s.panicStack = stack
+			} else {
+				l.Error(r.Method+" "+cleanPath(r)+" Recovered from panic",
+					append(panicFields(r, s, rec, reqID, logReqID), zap.String("stack", string(stack)))...)
+			}
+
+			if s != nil {
+				for _, h := range s.cfg.panicHooks {
+					h(r, rec, stack)
+				}
+			}
+
+			if reqID != "" && !headerWritten(w) && w.Header().Get(middleware.RequestIDHeader) == "" {
+				w.Header().Set(middleware.RequestIDHeader, reqID)
+			}
+			for k, vals := range header {
+				w.Header()[k] = append([]string(nil), vals...)
+			}
+			w.WriteHeader(status)
 		}()
 
 		next.ServeHTTP(w, r)
 	})
 }
+
+// panicFields returns the fields of the entry Recoverer logs for the panic
+// rec.
+// s is the state of the request, if any.
+func panicFields(r *http.Request, s *state, rec any, reqID string, logReqID bool) []zap.Field {
+	httpRequest, _ := httputil.DumpRequest(r, false)
+	var fields []zap.Field
+	if s != nil && s.panicBody != nil {
+		body, truncated := truncateBytes(s.panicBody.bytes(), s.cfg.maxFieldLength)
+		httpRequest = append(httpRequest, body...)
+		fields = append(fields, zap.Bool("body_truncated", truncated || s.panicBody.truncated))
+	}
+
+	fields = append(fields,
+		zap.Any("error", rec),
+		zap.String("request", string(httpRequest)),
+	)
+	if logReqID {
+		fields = append(fields, zap.String("request_id", cleanRequestValue(r, reqID)))
+	}
+
+	return fields
+}
+
+// serve calls next, and recovers from panics not recovered by a Recoverer
+// mounted after the middleware, returning the recovered value and the stack
+// trace of the panic.
+func serve(next http.Handler, w http.ResponseWriter, r *http.Request) (rec any, stack []byte) {
+	defer func() {
+		if rec = recover(); rec != nil {
+			stack = debug.Stack()
+		}
+	}()
+
+	next.ServeHTTP(w, r)
+	return nil, nil
+}
+
+// headerWritten reports whether the header of w was already written, if it is
+// able to tell.
+func headerWritten(w http.ResponseWriter) bool {
+	ww, ok := w.(middleware.WrapResponseWriter)
+	return ok && ww.Status() != 0
+}
+
+// stderr is where problems are reported, that can't be logged.
+// It is a variable, so that it can be replaced in tests.
+var stderr io.Writer = os.Stderr
+
+// recoverRecovery is deferred while Recoverer handles the panic rec.
+// Should handling rec panic as well, it writes both panics to stderr, and
+// responds with status 500.
+func recoverRecovery(w http.ResponseWriter, rec any) {
+	nested := recover()
+	if nested == nil {
+		return
+	}
+
+	fmt.Fprintf(stderr, "chizap: panic while recovering from panic: %v\n\noriginal panic: %v\n\n%s\n",
+		nested, rec, debug.Stack())
+
+	w.WriteHeader(http.StatusInternalServerError)
+}
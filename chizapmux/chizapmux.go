@@ -0,0 +1,42 @@
+// Package chizapmux adapts chizap to routers built using gorilla/mux.
+package chizapmux
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/mavolin/chizap"
+)
+
+// Option returns a [chizap.Option] that determines route patterns using
+// [Routes].
+//
+// gorilla/mux only adds the matched route to the requests passed to its
+// middlewares, so the chizap middleware must be added using Router.Use.
+// To also log requests that didn't match a route, wrap the NotFoundHandler
+// and MethodNotAllowedHandler of the router as well:
+//
+//	mw := chizap.New(l, chizapmux.Option())
+//
+//	r := mux.NewRouter()
+//	r.Use(mw)
+//	r.NotFoundHandler = mw(http.NotFoundHandler())
+func Option() chizap.Option {
+	return chizap.WithRoutes(Routes)
+}
+
+// Routes is a [chizap.RouteFunc] returning the path template of the
+// gorilla/mux route that matched r, e.g. /users/{id}.
+func Routes(r *http.Request) string {
+	route := mux.CurrentRoute(r)
+	if route == nil {
+		return ""
+	}
+
+	tmpl, err := route.GetPathTemplate()
+	if err != nil {
+		return ""
+	}
+
+	return tmpl
+}
@@ -0,0 +1,18 @@
+module github.com/mavolin/chizap/chizapmux
+
+go 1.20
+
+require (
+	github.com/gorilla/mux v1.8.1
+	github.com/mavolin/chizap v1.1.0
+)
+
+require (
+	github.com/go-chi/chi/v5 v5.0.8 // indirect
+	go.uber.org/atomic v1.7.0 // indirect
+	go.uber.org/multierr v1.6.0 // indirect
+	go.uber.org/zap v1.24.0 // indirect
+)
+
+// chizap v1.1.0, which provides WithRoutes, is not released yet.
+replace github.com/mavolin/chizap => ../
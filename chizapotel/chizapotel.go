@@ -0,0 +1,172 @@
+// Package chizapotel provides an OpenTelemetry Logs bridge for chizap.
+//
+// It emits every completion entry of the chizap middleware as an
+// OpenTelemetry log record, so that OTLP-native backends receive access logs
+// without a zap exporter.
+package chizapotel
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/mavolin/chizap"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/log"
+	"go.opentelemetry.io/otel/log/global"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// DefaultName is the name of the [log.Logger] used by [Option], if no
+// [log.LoggerProvider] is passed.
+const DefaultName = "github.com/mavolin/chizap"
+
+// Option returns a [chizap.Option] that, in addition to writing completion
+// entries to zap, emits them as log records using a [log.Logger] named
+// [DefaultName], obtained from lp.
+//
+// If lp is nil, the global [log.LoggerProvider] is used.
+//
+// The records are emitted using the request's context, so that they are
+// correlated with the active span, if any.
+func Option(lp log.LoggerProvider) chizap.Option {
+	if lp == nil {
+		lp = global.GetLoggerProvider()
+	}
+
+	return chizap.WithCompletionHook(Hook(lp.Logger(DefaultName)))
+}
+
+// Hook returns a [chizap.CompletionHook] that emits completion entries as log
+// records using l.
+func Hook(l log.Logger) chizap.CompletionHook {
+	return func(r *http.Request, e zapcore.Entry, fields []zap.Field) {
+		ctx := r.Context()
+
+		sev, sevText := severity(e.Level)
+		if !l.Enabled(ctx, log.EnabledParameters{Severity: sev}) {
+			return
+		}
+
+		enc := zapcore.NewMapObjectEncoder()
+		for _, f := range fields {
+			f.AddTo(enc)
+		}
+
+		var rec log.Record
+		rec.SetTimestamp(e.Time)
+		rec.SetObservedTimestamp(time.Now())
+		rec.SetSeverity(sev)
+		rec.SetSeverityText(sevText)
+		rec.SetBody(attribute.StringValue(e.Message))
+
+		rec.AddAttributes(keyValues(enc.Fields)...)
+
+		l.Emit(ctx, rec)
+	}
+}
+
+func severity(lvl zapcore.Level) (log.Severity, string) {
+	switch lvl {
+	case zapcore.DebugLevel:
+		return log.SeverityDebug, "DEBUG"
+	case zapcore.InfoLevel:
+		return log.SeverityInfo, "INFO"
+	case zapcore.WarnLevel:
+		return log.SeverityWarn, "WARN"
+	case zapcore.ErrorLevel:
+		return log.SeverityError, "ERROR"
+	case zapcore.DPanicLevel, zapcore.PanicLevel:
+		return log.SeverityFatal1, lvl.CapitalString()
+	case zapcore.FatalLevel:
+		return log.SeverityFatal2, "FATAL"
+	default:
+		return log.SeverityUndefined, lvl.CapitalString()
+	}
+}
+
+// value converts a value produced by a [zapcore.MapObjectEncoder] to a
+// [attribute.Value].
+func value(v any) attribute.Value {
+	switch v := v.(type) {
+	case nil:
+		return attribute.Value{}
+	case string:
+		return attribute.StringValue(v)
+	case bool:
+		return attribute.BoolValue(v)
+	case int:
+		return attribute.IntValue(v)
+	case int8:
+		return attribute.Int64Value(int64(v))
+	case int16:
+		return attribute.Int64Value(int64(v))
+	case int32:
+		return attribute.Int64Value(int64(v))
+	case int64:
+		return attribute.Int64Value(v)
+	case uint:
+		return uintValue(uint64(v))
+	case uint8:
+		return attribute.Int64Value(int64(v))
+	case uint16:
+		return attribute.Int64Value(int64(v))
+	case uint32:
+		return attribute.Int64Value(int64(v))
+	case uint64:
+		return uintValue(v)
+	case uintptr:
+		return uintValue(uint64(v))
+	case float32:
+		return attribute.Float64Value(float64(v))
+	case float64:
+		return attribute.Float64Value(v)
+	case []byte:
+		return attribute.ByteSliceValue(v)
+	case time.Duration:
+		return attribute.StringValue(v.String())
+	case time.Time:
+		return attribute.StringValue(v.Format(time.RFC3339Nano))
+	case map[string]any:
+		return attribute.MapValue(keyValues(v)...)
+	case []any:
+		vals := make([]attribute.Value, len(v))
+		for i, e := range v {
+			vals[i] = value(e)
+		}
+		return attribute.SliceValue(vals...)
+	case error:
+		return attribute.StringValue(v.Error())
+	case fmt.Stringer:
+		return attribute.StringValue(v.String())
+	default:
+		return attribute.StringValue(fmt.Sprint(v))
+	}
+}
+
+// keyValues converts m to key-values, sorted by key.
+func keyValues(m map[string]any) []attribute.KeyValue {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	kvs := make([]attribute.KeyValue, len(keys))
+	for i, k := range keys {
+		kvs[i] = attribute.KeyValue{Key: attribute.Key(k), Value: value(m[k])}
+	}
+
+	return kvs
+}
+
+func uintValue(v uint64) attribute.Value {
+	if v > math.MaxInt64 {
+		return attribute.StringValue(fmt.Sprint(v))
+	}
+
+	return attribute.Int64Value(int64(v))
+}
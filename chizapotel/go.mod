@@ -0,0 +1,26 @@
+module github.com/mavolin/chizap/chizapotel
+
+// go.opentelemetry.io/otel v1.45.0 is the first version providing
+// attribute.MapValue, and requires Go 1.25.
+go 1.25.0
+
+require (
+	github.com/mavolin/chizap v1.1.0
+	go.opentelemetry.io/otel v1.46.0
+	go.opentelemetry.io/otel/log v0.22.0
+	go.uber.org/zap v1.28.0
+)
+
+require (
+	github.com/cespare/xxhash/v2 v2.3.0 // indirect
+	github.com/go-chi/chi/v5 v5.0.8 // indirect
+	github.com/go-logr/logr v1.4.4 // indirect
+	github.com/go-logr/stdr v1.2.2 // indirect
+	go.opentelemetry.io/auto/sdk v1.2.1 // indirect
+	go.opentelemetry.io/otel/metric v1.46.0 // indirect
+	go.opentelemetry.io/otel/trace v1.46.0 // indirect
+	go.uber.org/multierr v1.10.0 // indirect
+)
+
+// chizap v1.1.0, which provides completion hooks, is not released yet.
+replace github.com/mavolin/chizap => ../
@@ -0,0 +1,61 @@
+// Package chizapsentry reports the panics recovered by chizap.Recoverer and
+// the errors attached using chizap.Error to Sentry.
+package chizapsentry
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/mavolin/chizap"
+)
+
+// Options returns the [chizap.Option]s that report panics and errors to
+// Sentry, using [PanicHook] and [ErrorHook]:
+//
+//	r.Use(chizap.New(l, chizapsentry.Options()...))
+func Options() []chizap.Option {
+	return []chizap.Option{
+		chizap.WithPanicHook(PanicHook),
+		chizap.WithErrorHook(ErrorHook),
+	}
+}
+
+// PanicHook is a [chizap.PanicHook] that reports the recovered panic to
+// Sentry.
+//
+// It uses the hub of the request context, as added by
+// [github.com/getsentry/sentry-go/http], or a clone of the current hub, if
+// there is none.
+func PanicHook(r *http.Request, rec any, _ []byte) {
+	hub := hubFor(r)
+
+	ctx := context.WithValue(r.Context(), sentry.RequestContextKey, r)
+	hub.RecoverWithContext(ctx, rec)
+}
+
+// ErrorHook is a [chizap.ErrorHook] that reports the error to Sentry.
+//
+// It uses the same hub as [PanicHook].
+func ErrorHook(r *http.Request, err error) {
+	hubFor(r).CaptureException(err)
+}
+
+// hubFor returns the hub to use for r, with the request and its ID set on its
+// scope.
+func hubFor(r *http.Request) *sentry.Hub {
+	hub := sentry.GetHubFromContext(r.Context())
+	if hub == nil {
+		hub = sentry.CurrentHub().Clone()
+	}
+
+	hub.ConfigureScope(func(scope *sentry.Scope) {
+		scope.SetRequest(r)
+		if id := middleware.GetReqID(r.Context()); id != "" {
+			scope.SetTag("request_id", id)
+		}
+	})
+
+	return hub
+}
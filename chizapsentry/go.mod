@@ -0,0 +1,20 @@
+module github.com/mavolin/chizap/chizapsentry
+
+go 1.20
+
+require (
+	github.com/getsentry/sentry-go v0.29.1
+	github.com/go-chi/chi/v5 v5.0.8
+	github.com/mavolin/chizap v1.1.0
+)
+
+require (
+	go.uber.org/atomic v1.7.0 // indirect
+	go.uber.org/multierr v1.6.0 // indirect
+	go.uber.org/zap v1.24.0 // indirect
+	golang.org/x/sys v0.30.0 // indirect
+	golang.org/x/text v0.22.0 // indirect
+)
+
+// chizap v1.1.0, which provides panic and error hooks, is not released yet.
+replace github.com/mavolin/chizap => ../
@@ -0,0 +1,93 @@
+package chizap
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// A Closer stops the background goroutines of the middlewares it was passed
+// to using [WithCloser], e.g. the one draining the queue of [WithAsync].
+//
+// A Closer must be created using [NewCloser], and is safe for concurrent use.
+type Closer struct {
+	mu     sync.Mutex
+	closed bool
+	funcs  []func() error
+}
+
+// NewCloser creates a new [Closer].
+func NewCloser() *Closer {
+	return new(Closer)
+}
+
+// WithCloser registers the background goroutines started by the middleware
+// with cl, so that they are stopped when cl is closed.
+//
+// Without a Closer, these goroutines run for the remainder of the program.
+func WithCloser(cl *Closer) Option {
+	return func(c *config) {
+		c.closer = cl
+	}
+}
+
+// Close stops the background goroutines of all middlewares registered with
+// cl, after writing all queued entries and logging the summaries of the
+// current interval, and then syncs their loggers.
+//
+// It should be called after the server was shut down, e.g. using
+// [net/http.Server.Shutdown].
+// Entries of requests completing after Close was called are written
+// synchronously, and are no longer summarized.
+//
+// Close blocks until it is done, or until ctx is done, in which case it
+// returns ctx.Err().
+// Otherwise, it returns the errors returned by syncing the loggers.
+// Calling Close more than once has no effect.
+func (cl *Closer) Close(ctx context.Context) error {
+	cl.mu.Lock()
+	if cl.closed {
+		cl.mu.Unlock()
+		return nil
+	}
+	cl.closed = true
+	funcs := cl.funcs
+	cl.funcs = nil
+	cl.mu.Unlock()
+
+	done := make(chan error, 1)
+	go func() {
+		errs := make([]error, 0, len(funcs))
+		for _, f := range funcs {
+			errs = append(errs, f())
+		}
+		done <- errors.Join(errs...)
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// add registers f to be called when cl is closed.
+// If cl was already closed, f is called immediately.
+//
+// add may be called on a nil Closer, in which case f is never called.
+func (cl *Closer) add(f func() error) {
+	if cl == nil {
+		return
+	}
+
+	cl.mu.Lock()
+	if !cl.closed {
+		cl.funcs = append(cl.funcs, f)
+		cl.mu.Unlock()
+		return
+	}
+	cl.mu.Unlock()
+
+	_ = f()
+}
@@ -0,0 +1,39 @@
+package chizap
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// MeasureUncompressed is a middleware that measures the size of the response
+// body before it is compressed.
+//
+// It must be mounted after both the middleware returned by [New] and
+// [github.com/go-chi/chi/v5/middleware.Compress], so that it is able to see
+// the uncompressed payload:
+//
+//	r.Use(chizap.Logger(l))
+//	r.Use(middleware.Compress(5))
+//	r.Use(chizap.MeasureUncompressed)
+//
+// If used, the completion entry will hold the following additional fields:
+//   - bytes_uncompressed: the number of bytes written by the handler, before
+//     compression
+//   - compression_ratio: bytes_uncompressed divided by bytes_written, which
+//     holds the number of bytes actually written on the wire
+func MeasureUncompressed(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s := getState(r)
+		if s == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+		next.ServeHTTP(ww, r)
+
+		s.measuredUncompressed = true
+		s.uncompressed += ww.BytesWritten()
+	})
+}
@@ -0,0 +1,93 @@
+package chizap
+
+import (
+	"net"
+	"net/http"
+	"net/netip"
+	"strconv"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+// WithConnInfo adds the following fields to both the logger saved in the
+// request context and the completion entry:
+//   - local_addr: the address of the listener that accepted the connection,
+//     allowing traffic of multiple listeners, e.g. an internal and a public
+//     port, to be told apart
+//   - alpn: the protocol negotiated using ALPN, if the connection uses TLS
+//   - conn_id: the ID of the connection, if the server uses [ConnContext]
+//   - conn_reused: whether the request arrived on a kept-alive connection
+//     that already served other requests, instead of a freshly accepted
+//     one, if the server uses both [ConnContext] and [ConnState], and the
+//     request uses HTTP/1.x
+func WithConnInfo() Option {
+	return func(c *config) {
+		c.connInfo = true
+	}
+}
+
+func appendConnFields(fields []zap.Field, r *http.Request) []zap.Field {
+	if addr, ok := r.Context().Value(http.LocalAddrContextKey).(net.Addr); ok {
+		fields = append(fields, zap.String("local_addr", addr.String()))
+	}
+
+	if r.TLS != nil {
+		fields = append(fields, zap.String("alpn", r.TLS.NegotiatedProtocol))
+	}
+
+	if tc := connFromContext(r.Context()); tc != nil {
+		fields = append(fields, zap.Uint64("conn_id", tc.id))
+
+		// HTTP/2 connections only become active once, regardless of the
+		// number of requests multiplexed over them.
+		if n := tc.requests.Load(); n > 0 && r.ProtoMajor == 1 {
+			fields = append(fields, zap.Bool("conn_reused", n > 1))
+		}
+	}
+
+	return fields
+}
+
+// appendRemoteFields appends the remote_ip and remote_port fields, parsed
+// from addr.
+// If addr has no port, remote_port is omitted, and if it has no IP, e.g.
+// because the connection was made over a unix socket, both are.
+func appendRemoteFields(fields []zap.Field, addr string) []zap.Field {
+	ip, port, ok := splitRemoteAddr(addr)
+	if !ok {
+		return fields
+	}
+
+	fields = append(fields, zap.String("remote_ip", ip.String()))
+	if p, err := strconv.Atoi(port); err == nil {
+		fields = append(fields, zap.Int("remote_port", p))
+	}
+
+	return fields
+}
+
+// remoteIP returns the IP of addr, or an empty string if it has none.
+func remoteIP(addr string) string {
+	ip, _, ok := splitRemoteAddr(addr)
+	if !ok {
+		return ""
+	}
+
+	return ip.String()
+}
+
+// splitRemoteAddr splits addr into its IP and, if it has one, its port.
+func splitRemoteAddr(addr string) (netip.Addr, string, bool) {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		host, port = strings.Trim(addr, "[]"), ""
+	}
+
+	ip, err := netip.ParseAddr(host)
+	if err != nil {
+		return netip.Addr{}, "", false
+	}
+
+	return ip, port, true
+}
@@ -0,0 +1,110 @@
+package chizap
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// trackedConn holds the bookkeeping of a connection tracked using
+// ConnContext or ConnState.
+type trackedConn struct {
+	id       uint64
+	accepted time.Time
+	requests atomic.Int64
+}
+
+var (
+	// conns maps the tracked net.Conns to their *trackedConn.
+	conns      sync.Map
+	lastConnID atomic.Uint64
+)
+
+// trackConn returns the bookkeeping of c, starting to track it if
+// necessary.
+func trackConn(c net.Conn) *trackedConn {
+	if tc, ok := conns.Load(c); ok {
+		return tc.(*trackedConn)
+	}
+
+	tc, _ := conns.LoadOrStore(c, &trackedConn{id: lastConnID.Add(1), accepted: time.Now()})
+	return tc.(*trackedConn)
+}
+
+type connKey struct{}
+
+// ConnContext is meant to be used as the ConnContext of an [http.Server],
+// and assigns every connection an ID, which is logged as conn_id, if
+// [WithConnInfo] is used:
+//
+//	srv := &http.Server{
+//		ConnContext: chizap.ConnContext,
+//		ConnState:   chizap.ConnState(l),
+//	}
+//
+// The IDs are the same as those logged by [ConnState], allowing
+// connection and request logs to be correlated.
+//
+// ConnContext must only be used together with [ConnState], which stops
+// tracking connections once they are closed.
+func ConnContext(ctx context.Context, c net.Conn) context.Context {
+	return context.WithValue(ctx, connKey{}, trackConn(c))
+}
+
+// connFromContext returns the bookkeeping of the connection of ctx, or nil,
+// if ConnContext wasn't used.
+func connFromContext(ctx context.Context) *trackedConn {
+	tc, _ := ctx.Value(connKey{}).(*trackedConn)
+	return tc
+}
+
+// ConnState returns a function meant to be used as the ConnState of an
+// [http.Server], that logs the lifecycle of connections using l, helping to
+// diagnose keep-alive and connection churn issues.
+//
+// New, closed, and hijacked connections are logged at info level, and
+// connections becoming active or idle at debug level.
+// Each entry holds the following fields:
+//   - conn_id: the ID of the connection, as also logged by [WithConnInfo],
+//     if [ConnContext] is used
+//   - remote: the remote address of the connection
+//   - state: the new state of the connection, e.g. idle
+//
+// Entries for closed and hijacked connections additionally hold:
+//   - conn_duration: the time since the connection was accepted
+//   - requests: the number of requests served over the connection
+func ConnState(l *zap.Logger) func(net.Conn, http.ConnState) {
+	return func(c net.Conn, state http.ConnState) {
+		tc := trackConn(c)
+
+		lvl := zap.InfoLevel
+		fields := []zap.Field{
+			zap.Uint64("conn_id", tc.id),
+			zap.String("remote", c.RemoteAddr().String()),
+			zap.String("state", state.String()),
+		}
+
+		switch state {
+		case http.StateActive:
+			tc.requests.Add(1)
+			lvl = zap.DebugLevel
+		case http.StateIdle:
+			lvl = zap.DebugLevel
+		case http.StateClosed, http.StateHijacked:
+			conns.Delete(c)
+			fields = append(fields,
+				zap.Duration("conn_duration", time.Since(tc.accepted)),
+				zap.Int64("requests", tc.requests.Load()),
+			)
+		}
+
+		if ce := l.Check(lvl, "connection "+state.String()); ce != nil {
+			ce.Write(fields...)
+		}
+	}
+}
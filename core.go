@@ -0,0 +1,123 @@
+package chizap
+
+import (
+	"context"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+const contextFieldKey = "chizap.context"
+
+// ContextField returns a field that carries ctx.
+//
+// When logged through a core wrapped using [WrapCore], the field is replaced
+// by the fields of the logger saved in the request context by the middleware
+// returned by [New], or, if ctx doesn't stem from such a request, by a
+// request_id field, if a request ID was set by
+// [github.com/go-chi/chi/v5/middleware.RequestID].
+//
+// All other cores ignore the field.
+func ContextField(ctx context.Context) zap.Field {
+	return zap.Field{Key: contextFieldKey, Type: zapcore.SkipType, Interface: ctx}
+}
+
+// WrapCore wraps the passed core, so that fields created by [ContextField]
+// are replaced by the request's fields.
+//
+// This enables request correlation for code that doesn't use [Get], e.g.
+// legacy code using the global logger, by passing the request's context:
+//
+//	zap.ReplaceGlobals(zap.New(chizap.WrapCore(core)))
+//
+//	// in a handler
+//	zap.L().Info("legacy code", chizap.ContextField(r.Context()))
+//
+// Since Go has no goroutine-local storage, entries logged without a
+// ContextField are not enriched.
+// Correlation is limited to the fields of the context logger, i.e. the
+// request_id field and the fields added through options, e.g. a trace ID
+// added using [WithContextValue].
+//
+// Whether an entry is written is decided by core, so that, e.g., sampling
+// and the levels of teed cores still apply.
+func WrapCore(core zapcore.Core) zapcore.Core {
+	return contextCore{core}
+}
+
+type contextCore struct {
+	zapcore.Core
+}
+
+func (c contextCore) With(fields []zapcore.Field) zapcore.Core {
+	return contextCore{c.Core.With(expandContextFields(fields))}
+}
+
+func (c contextCore) Check(e zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	inner := c.Core.Check(e, nil)
+	if inner == nil {
+		return ce
+	}
+
+	cc := &checkedCore{Core: c.Core, inner: inner}
+	cc.outer = ce.AddCore(e, cc)
+	return cc.outer
+}
+
+func (c contextCore) Write(e zapcore.Entry, fields []zapcore.Field) error {
+	return c.Core.Write(e, expandContextFields(fields))
+}
+
+// checkedCore writes the entry checked by the wrapped core of a contextCore,
+// expanding the context fields first.
+type checkedCore struct {
+	zapcore.Core
+	// inner is the entry checked by the wrapped core, and outer the entry
+	// checkedCore was added to.
+	inner, outer *zapcore.CheckedEntry
+}
+
+func (c *checkedCore) Write(_ zapcore.Entry, fields []zapcore.Field) error {
+	c.inner.ErrorOutput = c.outer.ErrorOutput
+	c.inner.Write(expandContextFields(fields)...)
+	return nil
+}
+
+// expandContextFields replaces all fields created by ContextField with the
+// fields of the request.
+func expandContextFields(fields []zapcore.Field) []zapcore.Field {
+	for i, f := range fields {
+		if f.Key != contextFieldKey || f.Type != zapcore.SkipType {
+			continue
+		}
+
+		expanded := make([]zapcore.Field, 0, len(fields)+8)
+		expanded = append(expanded, fields[:i]...)
+		for _, f := range fields[i:] {
+			if ctx, ok := f.Interface.(context.Context); ok && f.Key == contextFieldKey && f.Type == zapcore.SkipType {
+				expanded = append(expanded, requestFields(ctx)...)
+			} else {
+				expanded = append(expanded, f)
+			}
+		}
+
+		return expanded
+	}
+
+	return fields
+}
+
+// requestFields returns the fields of the request that ctx belongs to.
+func requestFields(ctx context.Context) []zapcore.Field {
+	if s, ok := ctx.Value(stateKey{}).(*state); ok {
+		s.initLogger()
+		return s.fields
+	}
+
+	if id := middleware.GetReqID(ctx); id != "" {
+		return []zapcore.Field{zap.String("request_id", EscapeControlChars(id))}
+	}
+
+	return nil
+}
@@ -0,0 +1,41 @@
+package chizap
+
+import (
+	"net/http"
+
+	"go.uber.org/zap"
+)
+
+// contextValue is a context value registered using WithContextValue.
+type contextValue struct {
+	key  any
+	name string
+}
+
+// WithContextValue adds the value stored under key in the request context as
+// a field with the passed name to the logger saved in the request context,
+// and thereby to the completion entry.
+//
+// This allows logging values set by earlier middlewares, e.g. the
+// authenticated principal or the enabled feature flags, without the
+// middlewares depending on chizap.
+// Since the value is read when the request reaches the middleware returned
+// by [New], only values set by middlewares mounted before it are logged.
+//
+// WithContextValue may be used multiple times to register multiple values.
+// If the request context holds no value for key, the field is omitted.
+func WithContextValue(key any, name string) Option {
+	return func(c *config) {
+		c.contextValues = append(c.contextValues, contextValue{key: key, name: name})
+	}
+}
+
+func appendContextValueFields(fields []zap.Field, r *http.Request, values []contextValue) []zap.Field {
+	for _, cv := range values {
+		if v := r.Context().Value(cv.key); v != nil {
+			fields = append(fields, zap.Any(cv.name, v))
+		}
+	}
+
+	return fields
+}
@@ -0,0 +1,143 @@
+package chizap
+
+import (
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// defaultRedactedHeaders are the headers always redacted in repro_curl.
+var defaultRedactedHeaders = []string{"Authorization", "Cookie", "Proxy-Authorization"}
+
+// defaultRedactedQueryParams are the query parameters always redacted in
+// repro_curl.
+var defaultRedactedQueryParams = []string{
+	"access_token", "api_key", "apikey", "key", "password", "secret", "sig", "signature", "token",
+}
+
+// WithReproCurl adds a repro_curl field to the completion entries of requests
+// that panicked or failed with a 5xx status, holding a curl command
+// equivalent to the request, so that it can be reproduced locally.
+//
+// The command includes up to maxBody bytes of the request body, as read by
+// the handler.
+// The values of the Authorization, Cookie, and Proxy-Authorization headers,
+// of the access_token, api_key, apikey, key, password, secret, sig,
+// signature, and token query parameters, and of the headers and query
+// parameters with one of the passed redacted names are replaced with
+// REDACTED.
+// Query parameter names are matched case-insensitively.
+//
+// Like other fields holding request data, the command is sanitized, and
+// truncated to the length set using [WithMaxFieldLength].
+func WithReproCurl(maxBody int, redacted ...string) Option {
+	return func(c *config) {
+		c.reproCurl = true
+		c.reproMaxBody = maxBody
+
+		c.reproRedacted = make(map[string]struct{}, len(defaultRedactedHeaders)+len(redacted))
+		for _, h := range defaultRedactedHeaders {
+			c.reproRedacted[http.CanonicalHeaderKey(h)] = struct{}{}
+		}
+		c.reproRedactedQuery = make(map[string]struct{}, len(defaultRedactedQueryParams)+len(redacted))
+		for _, p := range defaultRedactedQueryParams {
+			c.reproRedactedQuery[p] = struct{}{}
+		}
+
+		for _, name := range redacted {
+			c.reproRedacted[http.CanonicalHeaderKey(name)] = struct{}{}
+			c.reproRedactedQuery[strings.ToLower(name)] = struct{}{}
+		}
+	}
+}
+
+// curlCommand returns a curl command equivalent to r, with the passed body,
+// truncated to maxBody bytes, if maxBody is positive.
+// The values of the headers in redacted, and of the query parameters in
+// redactedQuery, are redacted.
+func curlCommand(
+	r *http.Request, body []byte, redacted, redactedQuery map[string]struct{}, maxBody int,
+) string {
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+
+	u := *r.URL
+	u.RawQuery = redactQuery(u.RawQuery, redactedQuery)
+
+	var b strings.Builder
+	// With -X HEAD, curl waits for a body that is never sent.
+	if r.Method == http.MethodHead {
+		b.WriteString("curl --head ")
+	} else {
+		b.WriteString("curl -X ")
+		b.WriteString(r.Method)
+		b.WriteByte(' ')
+	}
+	b.WriteString(shellQuote(scheme + "://" + r.Host + u.RequestURI()))
+
+	keys := make([]string, 0, len(r.Header))
+	for k := range r.Header {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		// curl computes the length of the body itself.
+		if k == "Content-Length" {
+			continue
+		}
+
+		for _, v := range r.Header[k] {
+			if _, ok := redacted[k]; ok {
+				v = "REDACTED"
+			}
+
+			b.WriteString(" -H ")
+			b.WriteString(shellQuote(k + ": " + v))
+		}
+	}
+
+	if len(body) > 0 {
+		b.WriteString(" --data-binary ")
+		body, _ = truncateBytes(body, maxBody)
+		b.WriteString(shellQuote(string(body)))
+	}
+
+	return b.String()
+}
+
+// redactQuery replaces the values of the parameters of the raw query, whose
+// lowercased names are in redacted, with REDACTED, leaving the rest of the
+// query untouched.
+func redactQuery(rawQuery string, redacted map[string]struct{}) string {
+	if rawQuery == "" {
+		return rawQuery
+	}
+
+	pairs := strings.Split(rawQuery, "&")
+	for i, pair := range pairs {
+		key, _, ok := strings.Cut(pair, "=")
+		if !ok {
+			continue
+		}
+
+		name, err := url.QueryUnescape(key)
+		if err != nil {
+			name = key
+		}
+
+		if _, ok := redacted[strings.ToLower(name)]; ok {
+			pairs[i] = key + "=REDACTED"
+		}
+	}
+
+	return strings.Join(pairs, "&")
+}
+
+// shellQuote quotes s for use as a single POSIX shell word.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
@@ -0,0 +1,133 @@
+package chizap
+
+import (
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// WithDedup suppresses identical completion entries logged at error level or
+// above, i.e. entries with the same route, status, and error, that occur
+// within window of the first such entry.
+//
+// Once window has passed, a single entry reporting the number of suppressed
+// entries in its repeated field is logged, if there were any, and the next
+// identical entry is logged as usual again.
+// This prevents a failing dependency from flooding the error logs.
+//
+// Use [WithLevel] to log completion entries at error level.
+func WithDedup(window time.Duration) Option {
+	return func(c *config) {
+		c.dedupWindow = window
+	}
+}
+
+type (
+	deduper struct {
+		l      *zap.Logger
+		window time.Duration
+
+		mu   sync.Mutex
+		seen map[dedupKey]*dedupEntry
+		// sweeper is a timer that flushes the expired entries of seen.
+		// It is running, if seen is not empty.
+		sweeper *time.Timer
+	}
+
+	dedupKey struct {
+		route  string
+		status int
+		err    string
+	}
+
+	dedupEntry struct {
+		key        dedupKey
+		msg        string
+		lvl        zapcore.Level
+		expires    time.Time
+		suppressed int
+	}
+)
+
+func newDeduper(l *zap.Logger, window time.Duration) *deduper {
+	return &deduper{l: l, window: window, seen: make(map[dedupKey]*dedupEntry)}
+}
+
+// suppress reports whether the entry with the passed key should be
+// suppressed.
+func (d *deduper) suppress(msg string, lvl zapcore.Level, key dedupKey) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if e, ok := d.seen[key]; ok {
+		e.suppressed++
+		return true
+	}
+
+	d.seen[key] = &dedupEntry{key: key, msg: msg, lvl: lvl, expires: time.Now().Add(d.window)}
+	if len(d.seen) == 1 {
+		if d.sweeper == nil {
+			d.sweeper = time.AfterFunc(d.window, d.sweep)
+		} else {
+			d.sweeper.Reset(d.window)
+		}
+	}
+
+	return false
+}
+
+// sweep flushes and forgets about all expired entries, and resets the sweeper
+// to the expiry of the oldest remaining entry, if any.
+func (d *deduper) sweep() {
+	now := time.Now()
+
+	d.mu.Lock()
+	var (
+		expired []*dedupEntry
+		next    time.Time
+	)
+	for key, e := range d.seen {
+		if !e.expires.After(now) {
+			expired = append(expired, e)
+			delete(d.seen, key)
+		} else if next.IsZero() || e.expires.Before(next) {
+			next = e.expires
+		}
+	}
+	if !next.IsZero() {
+		d.sweeper.Reset(next.Sub(now))
+	}
+	d.mu.Unlock()
+
+	sort.Slice(expired, func(i, j int) bool { return expired[i].expires.Before(expired[j].expires) })
+	for _, e := range expired {
+		d.flush(e)
+	}
+}
+
+// flush logs the number of entries suppressed because of e, if any.
+func (d *deduper) flush(e *dedupEntry) {
+	if e.suppressed == 0 {
+		return
+	}
+
+	ce := d.l.Check(e.lvl, e.msg+" (repeated "+strconv.Itoa(e.suppressed)+" times)")
+	if ce == nil {
+		return
+	}
+
+	fields := []zap.Field{
+		zap.String("route", e.key.route),
+		zap.Int("status", e.key.status),
+		zap.Int("repeated", e.suppressed),
+	}
+	if e.key.err != "" {
+		fields = append(fields, zap.String("error", e.key.err))
+	}
+
+	ce.Write(fields...)
+}
@@ -0,0 +1,50 @@
+package chizap
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Detach returns the logger saved in the request context, and a context
+// holding the values of the request context, but not bound to its
+// cancellation or deadline.
+//
+// Use it for goroutines that outlive the request, but whose logs should
+// remain correlated with it:
+//
+//	l, ctx := chizap.Detach(r)
+//	go func() {
+//		if err := sendMail(ctx, user); err != nil {
+//			l.Error("unable to send mail", zap.Error(err))
+//		}
+//	}()
+//
+// If the request wasn't handled by the middleware returned by [New], Detach
+// returns the global logger, as returned by [zap.L].
+func Detach(r *http.Request) (*zap.Logger, context.Context) {
+	ctx := detachedContext{parent: r.Context()}
+
+	if s := getState(r); s != nil {
+		s.diag.checkRequestID(s, r)
+		return s.logger(), ctx
+	}
+
+	return zap.L(), ctx
+}
+
+// detachedContext is a context holding the values of its parent, but that is
+// never canceled.
+type detachedContext struct {
+	parent context.Context
+}
+
+func (detachedContext) Deadline() (time.Time, bool) { return time.Time{}, false }
+func (detachedContext) Done() <-chan struct{}       { return nil }
+func (detachedContext) Err() error                  { return nil }
+
+func (ctx detachedContext) Value(key any) any {
+	return ctx.parent.Value(key)
+}
@@ -0,0 +1,99 @@
+package chizap
+
+import (
+	"context"
+	"net/http"
+	"sync"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"go.uber.org/zap"
+)
+
+// WithStrictOrdering makes the middleware panic, instead of logging a
+// warning, if it detects that it is mounted in the wrong order relative to
+// [Recoverer] or [github.com/go-chi/chi/v5/middleware.RequestID].
+//
+// By default, a single warning is logged per misordering.
+//
+// Note that a RequestID mounted after the middleware can only be detected
+// once [Get] is called.
+func WithStrictOrdering() Option {
+	return func(c *config) {
+		c.strictOrdering = true
+	}
+}
+
+// recovererKey is the context key Recoverer uses to mark requests, if it is
+// mounted before the middleware returned by New.
+type recovererKey struct{}
+
+// recovererMark is the value stored under recovererKey.
+// The middleware returned by New stores the request's state in it, so that
+// Recoverer can log panics re-raised by the middleware using the request's
+// logger.
+type recovererMark struct {
+	s *state
+}
+
+// diagnostics reports middleware misorderings.
+type diagnostics struct {
+	l      *zap.Logger
+	strict bool
+
+	requestIDOnce sync.Once
+	recovererOnce sync.Once
+}
+
+func (d *diagnostics) report(once *sync.Once, msg string) {
+	if d.strict {
+		panic("chizap: " + msg)
+	}
+
+	once.Do(func() { d.l.Warn(msg) })
+}
+
+// checkRecoverer reports if r was marked by a Recoverer mounted before the
+// middleware.
+func (d *diagnostics) checkRecoverer(r *http.Request) {
+	if r.Context().Value(recovererKey{}) != nil {
+		d.report(&d.recovererOnce, "chizap.Recoverer is mounted before chizap.Logger, "+
+			"so panics unwind through chizap.Logger and all middlewares mounted in between")
+	}
+}
+
+// checkRequestID reports if r has a request ID, although the request the
+// middleware saw didn't, which means that RequestID is mounted after the
+// middleware.
+func (d *diagnostics) checkRequestID(s *state, r *http.Request) {
+	if s.noRequestID && s.cfg.has(FieldRequestID) && middleware.GetReqID(r.Context()) != "" {
+		d.report(&d.requestIDOnce, "middleware.RequestID is mounted after chizap.Logger, "+
+			"so the request_id field is always empty")
+	}
+}
+
+// markRecoverer marks r as having passed Recoverer, if it hasn't passed the
+// middleware returned by New yet.
+func markRecoverer(r *http.Request) *http.Request {
+	if getState(r) != nil {
+		return r
+	}
+
+	return r.WithContext(context.WithValue(r.Context(), recovererKey{}, new(recovererMark)))
+}
+
+// markState stores s in the mark of r, if r was marked by Recoverer.
+func markState(r *http.Request, s *state) {
+	if m, ok := r.Context().Value(recovererKey{}).(*recovererMark); ok {
+		m.s = s
+	}
+}
+
+// markedState returns the state stored in the mark of r, or nil, if r wasn't
+// marked by Recoverer or no state was stored.
+func markedState(r *http.Request) *state {
+	if m, ok := r.Context().Value(recovererKey{}).(*recovererMark); ok {
+		return m.s
+	}
+
+	return nil
+}
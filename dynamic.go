@@ -0,0 +1,99 @@
+package chizap
+
+import (
+	"math/rand"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// Config is the part of the middleware's configuration that can be changed
+// at runtime, using a [DynamicConfig].
+type Config struct {
+	// ExcludedPaths are path prefixes excluded from being logged, in addition
+	// to those passed to [WithExcludedPaths].
+	ExcludedPaths []string
+	// SampleRate is the fraction of completion entries below warn level that
+	// are logged, e.g. 0.1 to log only every tenth such entry on average.
+	//
+	// If SampleRate is 0 or greater than or equal to 1, all entries are
+	// logged.
+	SampleRate float64
+	// SlowThreshold is the latency at or above which a request is considered
+	// slow.
+	// The completion entries of slow requests are logged at least at warn
+	// level, and hold a slow field set to true.
+	//
+	// If SlowThreshold is 0, requests are never considered slow.
+	SlowThreshold time.Duration
+}
+
+// DynamicConfig holds a [Config] that can be swapped atomically, allowing the
+// behavior of the middleware to be changed at runtime, e.g. by a config
+// watcher, without restarting.
+//
+// A DynamicConfig is safe for concurrent use.
+// It must be created using [NewDynamicConfig].
+type DynamicConfig struct {
+	v atomic.Pointer[Config]
+}
+
+// NewDynamicConfig creates a new [DynamicConfig] holding the passed config.
+func NewDynamicConfig(c Config) *DynamicConfig {
+	var dc DynamicConfig
+	dc.Update(c)
+	return &dc
+}
+
+// Update atomically replaces the config.
+// It takes effect for all requests arriving after Update returns.
+func (dc *DynamicConfig) Update(c Config) {
+	c.ExcludedPaths = append([]string(nil), c.ExcludedPaths...)
+	dc.v.Store(&c)
+}
+
+// Load returns the current config.
+func (dc *DynamicConfig) Load() Config {
+	return *dc.v.Load()
+}
+
+// WithDynamicConfig makes the middleware use dc, which may be updated at
+// runtime.
+func WithDynamicConfig(dc *DynamicConfig) Option {
+	return func(c *config) {
+		c.dynamic = dc
+	}
+}
+
+// loadDynamic returns the current dynamic config, or the zero value if there
+// is none.
+func (c *config) loadDynamic() *Config {
+	if c.dynamic == nil {
+		return new(Config)
+	}
+
+	return c.dynamic.v.Load()
+}
+
+func (dyn *Config) isExcluded(path string) bool {
+	for _, prefix := range dyn.ExcludedPaths {
+		if strings.HasPrefix(path, prefix) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// sampledOut reports whether an entry should be dropped due to sampling.
+func (dyn *Config) sampledOut() bool {
+	if dyn.SampleRate <= 0 || dyn.SampleRate >= 1 {
+		return false
+	}
+
+	return rand.Float64() >= dyn.SampleRate //nolint:gosec // no need for crypto/rand
+}
+
+func (dyn *Config) isSlow(lat time.Duration) bool {
+	return dyn.SlowThreshold > 0 && lat >= dyn.SlowThreshold
+}
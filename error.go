@@ -0,0 +1,86 @@
+package chizap
+
+import (
+	"errors"
+	"net/http"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// Error attaches err to the request, to be logged as the error field of the
+// completion entry.
+//
+// Error may be called multiple times, e.g. to record multiple non-fatal
+// failures.
+// In that case, the errors are logged as the errors array instead, and
+// combined using [errors.Join] wherever a single error is exposed, e.g. in
+// [Completion].
+//
+// If err, or an error it wraps, implements [zapcore.ObjectMarshaler], it is
+// additionally logged structurally as the error_details field.
+//
+// If err, or an error it wraps, has an HTTPStatus() int method, and the
+// handler returns without writing a response, that status is written.
+//
+// The error is also passed to the hooks added using [WithErrorHook].
+//
+// If the request wasn't handled by the middleware returned by [New], Error is
+// a no-op.
+func Error(r *http.Request, err error) {
+	s := getState(r)
+	if s == nil {
+		return
+	}
+
+	s.valuesMu.Lock()
+	s.errs = append(s.errs, err)
+	s.err = errors.Join(s.errs...)
+	s.valuesMu.Unlock()
+
+	for _, h := range s.cfg.errorHooks {
+		h(r, err)
+	}
+}
+
+// errorStatus returns the status carried by err, or 0 if it carries none.
+func errorStatus(err error) int {
+	var se interface{ HTTPStatus() int }
+	if errors.As(err, &se) {
+		return se.HTTPStatus()
+	}
+
+	return 0
+}
+
+// attachedError returns the errors attached using Error, combined using
+// errors.Join, or nil, if none were attached.
+func (s *state) attachedError() error {
+	s.valuesMu.Lock()
+	defer s.valuesMu.Unlock()
+
+	return s.err
+}
+
+// appendErrorFields appends the fields describing the errors attached using
+// Error to fields, if any.
+func (s *state) appendErrorFields(fields []zap.Field) []zap.Field {
+	s.valuesMu.Lock()
+	defer s.valuesMu.Unlock()
+
+	switch len(s.errs) {
+	case 0:
+		return fields
+	case 1:
+		fields = append(fields, zap.Error(s.errs[0]))
+	default:
+		fields = append(fields, zap.Errors("errors", s.errs))
+	}
+
+	var m zapcore.ObjectMarshaler
+	if errors.As(s.err, &m) {
+		fields = append(fields, zap.Object("error_details", m))
+	}
+
+	return fields
+}
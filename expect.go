@@ -0,0 +1,34 @@
+package chizap
+
+import (
+	"net/http"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+// WithExpectContinue adds the following fields to the completion entries of
+// requests with an Expect: 100-continue header, helping to debug rejected
+// uploads:
+//   - expect_continue: always true
+//   - body_read: whether the handler read the body, which causes net/http to
+//     send the 100 Continue response; false means the request was rejected
+//     before the client sent its body
+//   - body_bytes_read: the number of body bytes read by the handler
+func WithExpectContinue() Option {
+	return func(c *config) {
+		c.expectContinue = true
+	}
+}
+
+func expectsContinue(r *http.Request) bool {
+	return strings.EqualFold(r.Header.Get("Expect"), "100-continue")
+}
+
+func appendExpectContinueFields(fields []zap.Field, t *bodyTracker) []zap.Field {
+	return append(fields,
+		zap.Bool("expect_continue", true),
+		zap.Bool("body_read", t.read),
+		zap.Int64("body_bytes_read", t.bytes),
+	)
+}
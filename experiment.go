@@ -0,0 +1,58 @@
+package chizap
+
+import (
+	"net/http"
+
+	"go.uber.org/zap"
+)
+
+// WithExperiment adds the experiment and variant fields to the completion
+// entry, holding the A/B experiment and the variant the request was assigned
+// to, as returned by f, so that conversion and error rates can be analyzed
+// per variant.
+//
+// Empty values are omitted.
+//
+// [ExperimentFromHeaders] and [ExperimentFromCookies] may be used as f.
+func WithExperiment(f func(r *http.Request) (experiment, variant string)) Option {
+	return func(c *config) {
+		c.experiment = f
+	}
+}
+
+// ExperimentFromHeaders returns a function that extracts the experiment and
+// variant from the passed request headers.
+func ExperimentFromHeaders(experimentHeader, variantHeader string) func(r *http.Request) (string, string) {
+	return func(r *http.Request) (string, string) {
+		return r.Header.Get(experimentHeader), r.Header.Get(variantHeader)
+	}
+}
+
+// ExperimentFromCookies returns a function that extracts the experiment and
+// variant from the passed cookies.
+func ExperimentFromCookies(experimentCookie, variantCookie string) func(r *http.Request) (string, string) {
+	return func(r *http.Request) (string, string) {
+		return cookieValue(r, experimentCookie), cookieValue(r, variantCookie)
+	}
+}
+
+func cookieValue(r *http.Request, name string) string {
+	c, err := r.Cookie(name)
+	if err != nil {
+		return ""
+	}
+
+	return c.Value
+}
+
+func (c *config) appendExperimentFields(fields []zap.Field, r *http.Request) []zap.Field {
+	experiment, variant := c.experiment(r)
+	if experiment != "" {
+		fields = append(fields, zap.String("experiment", c.cleanField(experiment)))
+	}
+	if variant != "" {
+		fields = append(fields, zap.String("variant", c.cleanField(variant)))
+	}
+
+	return fields
+}
@@ -0,0 +1,153 @@
+package chizap
+
+import (
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// An IPObserver observes the client IP, status, and path of every request
+// handled by the middleware, e.g. to detect brute-force attempts or
+// scanners.
+//
+// Observe is called concurrently, and must therefore be safe for concurrent
+// use.
+type IPObserver interface {
+	Observe(ip string, status int, path string)
+}
+
+// WithIPObserver adds an [IPObserver] to the middleware.
+//
+// It is called for all requests, except those excluded from logging.
+// The client IP is taken from the CDN headers, if [WithCDN] is used, the
+// proxy headers, if [WithProxyHeaders] is used, or otherwise from the remote
+// address.
+func WithIPObserver(o IPObserver) Option {
+	return func(c *config) {
+		c.ipObservers = append(c.ipObservers, o)
+	}
+}
+
+// clientIP returns the IP of the client, as described in WithIPObserver.
+func (c *config) clientIP(r *http.Request) string {
+	if c.cdn != nil {
+		if ip := headerValue(r.Header, c.cdn.ClientIPHeader); ip != "" {
+			return ip
+		}
+	}
+
+	if c.proxyHeaders {
+		if ip := resolveClientIP(r.Header, parseForwarded(r.Header)); ip != "" {
+			return ip
+		}
+	}
+
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		return host
+	}
+
+	return r.RemoteAddr
+}
+
+// FailureAggregator is an [IPObserver] that logs a warning if a client IP
+// receives more than a given number of 401, 403, or 404 responses within a
+// sliding window.
+//
+// It must be created using [NewFailureAggregator].
+type FailureAggregator struct {
+	l         *zap.Logger
+	threshold int
+	window    time.Duration
+
+	mu        sync.Mutex
+	failures  map[string]*ipFailures
+	lastSweep time.Time
+}
+
+type ipFailures struct {
+	// times holds the times of the most recent failures, at most threshold+1.
+	times  []time.Time
+	warned time.Time
+}
+
+var _ IPObserver = (*FailureAggregator)(nil)
+
+// NewFailureAggregator creates a new [FailureAggregator] that logs a warning
+// using l, if an IP receives more than threshold 401, 403, or 404 responses
+// within window.
+//
+// At most one warning is logged per IP and window.
+func NewFailureAggregator(l *zap.Logger, threshold int, window time.Duration) *FailureAggregator {
+	return &FailureAggregator{
+		l:         l,
+		threshold: threshold,
+		window:    window,
+		failures:  make(map[string]*ipFailures),
+		lastSweep: time.Now(),
+	}
+}
+
+// Observe implements [IPObserver].
+func (a *FailureAggregator) Observe(ip string, status int, path string) {
+	if status != http.StatusUnauthorized && status != http.StatusForbidden && status != http.StatusNotFound {
+		return
+	}
+
+	now := time.Now()
+	cutoff := now.Add(-a.window)
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if now.Sub(a.lastSweep) >= a.window {
+		a.sweep(cutoff)
+		a.lastSweep = now
+	}
+
+	f, ok := a.failures[ip]
+	if !ok {
+		f = new(ipFailures)
+		a.failures[ip] = f
+	}
+
+	f.times = append(prune(f.times, cutoff), now)
+	// Exceeding the threshold is all that matters, so don't let a scanner
+	// grow the times without bound.
+	if keep := a.threshold + 1; keep > 0 && len(f.times) > keep {
+		f.times = f.times[len(f.times)-keep:]
+	}
+	if len(f.times) <= a.threshold || f.warned.After(cutoff) {
+		return
+	}
+
+	f.warned = now
+	a.l.Warn("client exceeded failure threshold",
+		zap.String("client_ip", ip),
+		zap.Int("failures", len(f.times)),
+		zap.Duration("window", a.window),
+		zap.Int("last_status", status),
+		zap.String("last_path", path),
+	)
+}
+
+// sweep removes all IPs without failures after cutoff.
+func (a *FailureAggregator) sweep(cutoff time.Time) {
+	for ip, f := range a.failures {
+		if f.times = prune(f.times, cutoff); len(f.times) == 0 {
+			delete(a.failures, ip)
+		}
+	}
+}
+
+// prune removes all times before cutoff from the sorted times.
+func prune(times []time.Time, cutoff time.Time) []time.Time {
+	i := 0
+	for i < len(times) && times[i].Before(cutoff) {
+		i++
+	}
+
+	return times[i:]
+}
@@ -0,0 +1,228 @@
+package chizap
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+func appendRangeFields(
+	fields []zap.Field, r *http.Request, ww middleware.WrapResponseWriter, clean func(string) string,
+) []zap.Field {
+	rangeHeader := r.Header.Get("Range")
+	partial := ww.Status() == http.StatusPartialContent
+	if rangeHeader == "" && !partial {
+		return fields
+	}
+
+	fields = append(fields, zap.String("range", clean(rangeHeader)), zap.Bool("partial_content", partial))
+	if partial {
+		fields = append(fields, zap.String("content_range", clean(ww.Header().Get("Content-Range"))))
+	}
+
+	return fields
+}
+
+func appendCacheStatusField(
+	fields []zap.Field, headers []string, ww middleware.WrapResponseWriter, clean func(string) string,
+) []zap.Field {
+	for _, h := range headers {
+		if v := ww.Header().Get(h); v != "" {
+			return append(fields, zap.String("cache_status", clean(v)))
+		}
+	}
+
+	return fields
+}
+
+func isPreflight(r *http.Request) bool {
+	return r.Method == http.MethodOptions &&
+		r.Header.Get("Origin") != "" && r.Header.Get("Access-Control-Request-Method") != ""
+}
+
+const rateLimitPrefix = "X-Ratelimit-"
+
+func appendRateLimitFields(
+	fields []zap.Field, ww middleware.WrapResponseWriter, clean func(string) string,
+) []zap.Field {
+	h := ww.Header()
+
+	var limitKeys []string
+	for k := range h {
+		if strings.HasPrefix(k, rateLimitPrefix) {
+			limitKeys = append(limitKeys, k)
+		}
+	}
+
+	limited := ww.Status() == http.StatusTooManyRequests
+	retryAfter := h.Get("Retry-After")
+	if !limited && retryAfter == "" && len(limitKeys) == 0 {
+		return fields
+	}
+
+	fields = append(fields, zap.Bool("rate_limited", limited))
+	if retryAfter != "" {
+		fields = append(fields, zap.String("retry_after", clean(retryAfter)))
+	}
+	if len(limitKeys) > 0 {
+		sort.Strings(limitKeys)
+		fields = append(fields, zap.Object("rate_limit", rateLimitObject{h: h, keys: limitKeys, clean: clean}))
+	}
+
+	return fields
+}
+
+type rateLimitObject struct {
+	h     http.Header
+	keys  []string
+	clean func(string) string
+}
+
+func (o rateLimitObject) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	for _, k := range o.keys {
+		enc.AddString(strings.ToLower(strings.TrimPrefix(k, rateLimitPrefix)), o.clean(o.h.Get(k)))
+	}
+
+	return nil
+}
+
+func hashString(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacString(key []byte, s string) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(s))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// sessionCredential returns the value of the first of the passed cookies that
+// is set, or the credentials of the Authorization header.
+func sessionCredential(r *http.Request, cookies []string) string {
+	for _, name := range cookies {
+		if c, err := r.Cookie(name); err == nil && c.Value != "" {
+			return c.Value
+		}
+	}
+
+	auth := r.Header.Get("Authorization")
+	if _, cred, ok := strings.Cut(auth, " "); ok {
+		return strings.TrimSpace(cred)
+	}
+
+	return auth
+}
+
+type authObject struct {
+	scheme        string
+	principalType string
+}
+
+func authField(r *http.Request, principalType func(*http.Request) string, clean func(string) string) zap.Field {
+	o := authObject{scheme: clean(authScheme(r))}
+	if principalType != nil {
+		o.principalType = clean(principalType(r))
+	}
+
+	return zap.Object("auth", o)
+}
+
+func (o authObject) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	enc.AddString("scheme", o.scheme)
+	if o.principalType != "" {
+		enc.AddString("principal_type", o.principalType)
+	}
+
+	return nil
+}
+
+func authScheme(r *http.Request) string {
+	if r.TLS != nil && len(r.TLS.PeerCertificates) > 0 {
+		return "mtls"
+	}
+
+	auth := r.Header.Get("Authorization")
+	if auth == "" {
+		return "none"
+	}
+
+	scheme, _, _ := strings.Cut(auth, " ")
+	return strings.ToLower(scheme)
+}
+
+func statusClass(status int) string {
+	return strconv.Itoa(status/100) + "xx"
+}
+
+func outcome(status int, s *state) string {
+	switch {
+	case s.panicked:
+		return "panic"
+	case s.timedOut:
+		return "timeout"
+	case status >= 500:
+		return "server_error"
+	case status >= 400:
+		return "client_error"
+	default:
+		return "success"
+	}
+}
+
+func appendRedirectField(fields []zap.Field, ww middleware.WrapResponseWriter, clean func(string) string) []zap.Field {
+	if ww.Status() < 300 || ww.Status() >= 400 {
+		return fields
+	}
+
+	if loc := ww.Header().Get("Location"); loc != "" {
+		fields = append(fields, zap.String("redirect_to", clean(loc)))
+	}
+
+	return fields
+}
+
+func appendConditionalFields(fields []zap.Field, r *http.Request, ww middleware.WrapResponseWriter) []zap.Field {
+	if r.Header.Get("If-None-Match") == "" && r.Header.Get("If-Modified-Since") == "" {
+		return fields
+	}
+
+	return append(fields,
+		zap.Bool("conditional", true),
+		zap.Bool("revalidated", ww.Status() == http.StatusNotModified),
+	)
+}
+
+// appendTargetFields appends the request_form and target_host fields, if r
+// uses the absolute form, e.g. when sent to a forward proxy, or the authority
+// form, used by CONNECT, instead of the usual origin form.
+func appendTargetFields(fields []zap.Field, r *http.Request, clean func(string) string) []zap.Field {
+	switch {
+	case r.Method == http.MethodConnect && r.URL.Path == "" && r.URL.Host != "":
+		fields = append(fields, zap.String("request_form", "authority"))
+	case r.URL.IsAbs():
+		fields = append(fields, zap.String("request_form", "absolute"))
+	default:
+		return fields
+	}
+
+	return append(fields, zap.String("target_host", clean(r.URL.Host)))
+}
+
+// messagePath returns the path used in the message of the completion entry,
+// which is the path of r, or, if r is in authority form, its host.
+func messagePath(r *http.Request) string {
+	if r.URL.Path == "" && r.URL.Host != "" {
+		return r.URL.Host
+	}
+
+	return r.URL.Path
+}
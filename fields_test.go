@@ -0,0 +1,88 @@
+package chizap
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestNew_RequestForm(t *testing.T) {
+	testCases := []struct {
+		name       string
+		method     string
+		target     string
+		expectMsg  string
+		expectForm string
+		expectHost string
+		expectPath string
+		expectQry  string
+	}{
+		{
+			name:       "origin form",
+			method:     http.MethodGet,
+			target:     "/a?b=c",
+			expectMsg:  "GET /a",
+			expectPath: "/a",
+			expectQry:  "b=c",
+		},
+		{
+			name:       "absolute form",
+			method:     http.MethodGet,
+			target:     "http://example.org/a?b=c",
+			expectMsg:  "GET /a",
+			expectForm: "absolute",
+			expectHost: "example.org",
+			expectPath: "/a",
+			expectQry:  "b=c",
+		},
+		{
+			name:       "authority form",
+			method:     http.MethodConnect,
+			target:     "example.org:443",
+			expectMsg:  "CONNECT example.org:443",
+			expectForm: "authority",
+			expectHost: "example.org:443",
+		},
+	}
+
+	for _, c := range testCases {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			core, logs := observer.New(zap.InfoLevel)
+
+			h := New(zap.New(core))(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+			h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(c.method, c.target, nil))
+
+			entries := logs.All()
+			if len(entries) != 1 {
+				t.Fatalf("expected 1 entry, but got %d", len(entries))
+			}
+
+			if entries[0].Message != c.expectMsg {
+				t.Errorf("expected message %q, but got %q", c.expectMsg, entries[0].Message)
+			}
+
+			fields := entries[0].ContextMap()
+
+			form, _ := fields["request_form"].(string)
+			if form != c.expectForm {
+				t.Errorf("expected request_form %q, but got %q", c.expectForm, form)
+			}
+
+			host, _ := fields["target_host"].(string)
+			if host != c.expectHost {
+				t.Errorf("expected target_host %q, but got %q", c.expectHost, host)
+			}
+
+			if fields["path"] != c.expectPath {
+				t.Errorf("expected path %q, but got %v", c.expectPath, fields["path"])
+			}
+			if fields["query"] != c.expectQry {
+				t.Errorf("expected query %q, but got %v", c.expectQry, fields["query"])
+			}
+		})
+	}
+}
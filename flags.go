@@ -0,0 +1,31 @@
+package chizap
+
+import (
+	"context"
+	"net/http"
+
+	"go.uber.org/zap"
+)
+
+// WithFeatureFlags adds a flags field to the completion entry, holding the
+// feature flags returned by f, e.g. ["new-checkout"], enabling flag-sliced
+// error analysis from the logs.
+//
+// f is called once the request completed, with the request context, so that
+// it can return the flags evaluated during the request, e.g. as recorded by
+// the feature flag SDK.
+// If f returns no flags, the field is omitted.
+func WithFeatureFlags(f func(ctx context.Context) []string) Option {
+	return func(c *config) {
+		c.featureFlags = f
+	}
+}
+
+func (c *config) appendFeatureFlagsField(fields []zap.Field, r *http.Request) []zap.Field {
+	flags := c.featureFlags(r.Context())
+	if len(flags) == 0 {
+		return fields
+	}
+
+	return append(fields, zap.Strings("flags", flags))
+}
@@ -0,0 +1,215 @@
+package chizap
+
+import (
+	"net/http"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+// WithProxyHeaders adds the following fields, resolved from the headers set
+// by reverse proxies, to both the logger saved in the request context and the
+// completion entry:
+//   - client_ip: the IP of the client, as reported by the first for
+//     parameter of the Forwarded header, the first entry of the
+//     X-Forwarded-For header, or the X-Real-IP header, in that order
+//   - forwarded_proto: the protocol used by the client, as reported by the
+//     proto parameter of the Forwarded header, or the X-Forwarded-Proto
+//     header
+//   - forwarded_host: the host requested by the client, as reported by the
+//     host parameter of the Forwarded header, or the X-Forwarded-Host header
+//
+// Fields that can't be resolved are omitted.
+//
+// Note that these headers are set by the client, and are only trustworthy,
+// if all requests pass a proxy that overwrites them.
+func WithProxyHeaders() Option {
+	return func(c *config) {
+		c.proxyHeaders = true
+	}
+}
+
+// WithProxyChain adds the following fields to both the logger saved in the
+// request context and the completion entry, allowing multi-proxy routing
+// issues to be debugged:
+//   - forwarded_for: the entries of the X-Forwarded-For header, as an array
+//   - via: the entries of the Via header, as an array
+//
+// Empty fields are omitted.
+// Use [WithProxyHeaders] to additionally log the resolved client IP.
+func WithProxyChain() Option {
+	return func(c *config) {
+		c.proxyChain = true
+	}
+}
+
+// forwarded holds the values of the first element of an RFC 7239 Forwarded
+// header.
+type forwarded struct {
+	forNode string
+	proto   string
+	host    string
+}
+
+// parseForwarded parses the first element of the Forwarded header of h.
+//
+// Values may be quoted strings, as described in RFC 7239 section 4, which
+// may contain the separators of pairs and elements.
+func parseForwarded(h http.Header) forwarded {
+	var f forwarded
+
+	s := h.Get("Forwarded")
+	for s != "" {
+		var (
+			key, val string
+			sep      byte
+		)
+		key, val, sep, s = cutForwardedPair(s)
+
+		switch strings.ToLower(key) {
+		case "for":
+			f.forNode = val
+		case "proto":
+			f.proto = val
+		case "host":
+			f.host = val
+		}
+
+		if sep == ',' {
+			break
+		}
+	}
+
+	return f
+}
+
+// cutForwardedPair cuts the first pair off the passed Forwarded header value
+// s, returning its key, its unquoted value, the separator following it, i.e.
+// ';' or ',', or 0 if s ends after the pair, and the rest of s.
+func cutForwardedPair(s string) (key, val string, sep byte, rest string) {
+	i := strings.IndexAny(s, "=;,")
+	switch {
+	case i < 0:
+		return strings.TrimSpace(s), "", 0, ""
+	case s[i] != '=':
+		return strings.TrimSpace(s[:i]), "", s[i], s[i+1:]
+	}
+
+	key = strings.TrimSpace(s[:i])
+	s = strings.TrimLeft(s[i+1:], " \t")
+
+	quoted := strings.HasPrefix(s, `"`)
+	if quoted {
+		var b strings.Builder
+
+		i = 1
+		for ; i < len(s) && s[i] != '"'; i++ {
+			// A quoted-pair, i.e. a backslash escaping the next character.
+			if s[i] == '\\' && i+1 < len(s) {
+				i++
+			}
+			b.WriteByte(s[i])
+		}
+
+		val = b.String()
+		if i < len(s) {
+			i++
+		}
+		s = s[i:]
+	}
+
+	end := strings.IndexAny(s, ";,")
+	if end < 0 {
+		end = len(s)
+	} else {
+		sep, rest = s[end], s[end+1:]
+	}
+
+	if !quoted {
+		val = strings.TrimSpace(s[:end])
+	}
+	return key, val, sep, rest
+}
+
+// nodeIP strips the port and IPv6 brackets from the passed node, as found in
+// the for parameter of the Forwarded header.
+// Unknown and obfuscated nodes are returned as-is.
+func nodeIP(node string) string {
+	if strings.HasPrefix(node, "[") {
+		if end := strings.IndexByte(node, ']'); end > 0 {
+			return node[1:end]
+		}
+		return node
+	}
+
+	if host, _, ok := strings.Cut(node, ":"); ok && strings.Count(node, ":") == 1 {
+		return host
+	}
+
+	return node
+}
+
+// appendProxyFields appends the fields resolved from the proxy headers.
+// If skipClientIP is true, the client_ip field is omitted.
+// The returned bool reports whether client_ip was skipped or added.
+func appendProxyFields(
+	fields []zap.Field, r *http.Request, skipClientIP bool, clean func(string) string,
+) ([]zap.Field, bool) {
+	f := parseForwarded(r.Header)
+
+	haveClientIP := skipClientIP
+	if !skipClientIP {
+		if clientIP := resolveClientIP(r.Header, f); clientIP != "" {
+			fields = append(fields, zap.String("client_ip", clean(clientIP)))
+			haveClientIP = true
+		}
+	}
+	if proto := firstNonEmpty(f.proto, r.Header.Get("X-Forwarded-Proto")); proto != "" {
+		fields = append(fields, zap.String("forwarded_proto", clean(proto)))
+	}
+	if host := firstNonEmpty(f.host, r.Header.Get("X-Forwarded-Host")); host != "" {
+		fields = append(fields, zap.String("forwarded_host", clean(host)))
+	}
+
+	return fields, haveClientIP
+}
+
+func appendProxyChainFields(fields []zap.Field, r *http.Request, clean func(string) string) []zap.Field {
+	if xff := splitHeaderList(r.Header.Values("X-Forwarded-For"), clean); len(xff) > 0 {
+		fields = append(fields, zap.Strings("forwarded_for", xff))
+	}
+	if via := splitHeaderList(r.Header.Values("Via"), clean); len(via) > 0 {
+		fields = append(fields, zap.Strings("via", via))
+	}
+
+	return fields
+}
+
+// splitHeaderList splits the comma-separated entries of the passed header
+// values, and sanitizes them using clean.
+func splitHeaderList(vals []string, clean func(string) string) []string {
+	var entries []string
+	for _, v := range vals {
+		for _, e := range strings.Split(v, ",") {
+			if e = strings.TrimSpace(e); e != "" {
+				entries = append(entries, clean(e))
+			}
+		}
+	}
+
+	return entries
+}
+
+// resolveClientIP resolves the client IP from the proxy headers of h, or
+// returns an empty string if none is set.
+func resolveClientIP(h http.Header, f forwarded) string {
+	if ip := nodeIP(f.forNode); ip != "" {
+		return ip
+	}
+
+	if xff, _, _ := strings.Cut(h.Get("X-Forwarded-For"), ","); strings.TrimSpace(xff) != "" {
+		return strings.TrimSpace(xff)
+	}
+
+	return h.Get("X-Real-IP")
+}
@@ -0,0 +1,74 @@
+package chizap
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestParseForwarded(t *testing.T) {
+	testCases := []struct {
+		name   string
+		header string
+		expect forwarded
+	}{
+		{
+			name:   "empty",
+			header: "",
+			expect: forwarded{},
+		},
+		{
+			name:   "tokens",
+			header: "for=192.0.2.60;proto=http;host=example.org",
+			expect: forwarded{forNode: "192.0.2.60", proto: "http", host: "example.org"},
+		},
+		{
+			name:   "first element only",
+			header: "for=192.0.2.60, for=198.51.100.17;proto=https",
+			expect: forwarded{forNode: "192.0.2.60"},
+		},
+		{
+			name:   "whitespace and case",
+			header: " For=192.0.2.60 ; PROTO=https",
+			expect: forwarded{forNode: "192.0.2.60", proto: "https"},
+		},
+		{
+			name:   "quoted ipv6",
+			header: `for="[2001:db8::1]:4711";proto=https`,
+			expect: forwarded{forNode: "[2001:db8::1]:4711", proto: "https"},
+		},
+		{
+			name:   "quoted separators",
+			header: `for="_a,b;c";host="example.org";proto=https, for=192.0.2.60`,
+			expect: forwarded{forNode: "_a,b;c", proto: "https", host: "example.org"},
+		},
+		{
+			name:   "quoted pair",
+			header: `for="_a\"b\\c";proto=https`,
+			expect: forwarded{forNode: `_a"b\c`, proto: "https"},
+		},
+		{
+			name:   "unterminated quote",
+			header: `proto=https;for="_a;b`,
+			expect: forwarded{forNode: "_a;b", proto: "https"},
+		},
+		{
+			name:   "pair without value",
+			header: "secret;for=192.0.2.60",
+			expect: forwarded{forNode: "192.0.2.60"},
+		},
+	}
+
+	for _, c := range testCases {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			h := make(http.Header)
+			if c.header != "" {
+				h.Set("Forwarded", c.header)
+			}
+
+			if actual := parseForwarded(h); actual != c.expect {
+				t.Errorf("expected %+v, but got %+v", c.expect, actual)
+			}
+		})
+	}
+}
@@ -1,10 +1,13 @@
 module github.com/mavolin/chizap
 
-go 1.19
+go 1.20
+
+require (
+	github.com/go-chi/chi/v5 v5.0.8
+	go.uber.org/zap v1.24.0
+)
 
 require (
-	github.com/go-chi/chi/v5 v5.0.8 // indirect
 	go.uber.org/atomic v1.7.0 // indirect
 	go.uber.org/multierr v1.6.0 // indirect
-	go.uber.org/zap v1.24.0 // indirect
 )
@@ -0,0 +1,62 @@
+package chizap
+
+import (
+	"context"
+	"net/http"
+	"runtime/debug"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"go.uber.org/zap"
+)
+
+// Go runs fn in a new goroutine, recovering from and logging any panic in it,
+// since [Recoverer] can't protect goroutines spawned by handlers.
+//
+// fn is passed a context obtained using [Detach], i.e. one that holds the
+// values of the request context, but isn't canceled when the request
+// completes.
+// The logger saved in the request context can be retrieved from it using
+// [FromContext].
+//
+// Panics are logged at error level, together with their stack trace, using
+// the logger saved in the request context, and passed to the hooks added
+// using [WithPanicHook].
+func Go(r *http.Request, fn func(ctx context.Context)) {
+	l, ctx := Detach(r)
+	msg := r.Method + " " + cleanPath(r) + " Recovered from panic in goroutine"
+
+	s := getState(r)
+
+	reqID := middleware.GetReqID(r.Context())
+	// Make sure the request ID is logged, even if the logger doesn't have it.
+	logReqID := reqID != "" && (s == nil || s.noRequestID)
+
+	go func() {
+		defer func() {
+			rec := recover()
+			if rec == nil {
+				return
+			}
+
+			stack := debug.Stack()
+
+			fields := []zap.Field{
+				zap.Any("error", rec),
+				zap.String("stack", string(stack)),
+			}
+			if logReqID {
+				fields = append(fields, zap.String("request_id", cleanRequestValue(r, reqID)))
+			}
+
+			l.Error(msg, fields...)
+
+			if s != nil {
+				for _, h := range s.cfg.panicHooks {
+					h(r, rec, stack)
+				}
+			}
+		}()
+
+		fn(ctx)
+	}()
+}
@@ -0,0 +1,117 @@
+package chizap
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"runtime"
+	"strings"
+	"sync"
+
+	"github.com/go-chi/chi/v5"
+	"go.uber.org/zap"
+)
+
+// Handler wraps h, so that name is logged as the handler field of the
+// completion entries of the requests it serves.
+//
+// The name set by Handler takes precedence over the one determined by
+// [WithHandlerNames].
+func Handler(name string, h http.Handler) http.Handler {
+	return &namedHandler{name: name, h: h}
+}
+
+// HandlerFunc is shorthand for:
+//
+//	Handler(name, http.HandlerFunc(f))
+func HandlerFunc(name string, f http.HandlerFunc) http.Handler {
+	return Handler(name, f)
+}
+
+type namedHandler struct {
+	name string
+	h    http.Handler
+}
+
+func (h *namedHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if s := getState(r); s != nil {
+		s.handler = h.name
+	}
+
+	h.h.ServeHTTP(w, r)
+}
+
+// WithHandlerNames adds a handler field to the completion entry, holding the
+// name of the handler that served the request, so that latency can be
+// attributed to code, not just URLs.
+//
+// Handlers wrapped using [Handler] use the name passed to it.
+// For other handlers registered with a chi router, the name is determined
+// using reflection: functions are named by their fully-qualified name, e.g.
+// github.com/owner/repo/api.(*Server).GetUser, and other handlers by their
+// type, e.g. *api.UserHandler.
+//
+// Without WithHandlerNames, the handler field is only added for handlers
+// wrapped using [Handler].
+func WithHandlerNames() Option {
+	return func(c *config) {
+		c.handlerNames = new(sync.Map)
+	}
+}
+
+type handlerKey struct {
+	routes        chi.Routes
+	method, route string
+}
+
+// handlerName returns the name of the chi handler that served r, or "" if it
+// cannot be determined.
+func (c *config) handlerName(r *http.Request, route string) string {
+	rctx := chi.RouteContext(r.Context())
+	if rctx == nil || rctx.Routes == nil || route == "" {
+		return ""
+	}
+
+	key := handlerKey{routes: rctx.Routes, method: r.Method, route: route}
+	if name, ok := c.handlerNames.Load(key); ok {
+		return name.(string)
+	}
+
+	var name string
+	_ = chi.Walk(rctx.Routes, func(method, walkRoute string, h http.Handler, _ ...func(http.Handler) http.Handler) error {
+		if walkRoute == route && (name == "" || method == r.Method) {
+			name = reflectHandlerName(h)
+		}
+		return nil
+	})
+
+	c.handlerNames.Store(key, name)
+	return name
+}
+
+// reflectHandlerName returns the name of h.
+func reflectHandlerName(h http.Handler) string {
+	switch h := h.(type) {
+	case *namedHandler:
+		return h.name
+	case http.HandlerFunc:
+		if f := runtime.FuncForPC(reflect.ValueOf(h).Pointer()); f != nil {
+			return strings.TrimSuffix(f.Name(), "-fm")
+		}
+	}
+
+	return fmt.Sprintf("%T", h)
+}
+
+func (c *config) appendHandlerField(fields []zap.Field, r *http.Request, s *state, route string) []zap.Field {
+	name := s.handler
+	if name == "" && c.handlerNames != nil {
+		name = c.handlerName(r, route)
+	}
+
+	if name == "" {
+		return fields
+	}
+
+	return append(fields, zap.String("handler", name))
+}
@@ -0,0 +1,283 @@
+package chizap
+
+import (
+	"encoding/json"
+	"io"
+	"math/rand"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// HARRecorder records request/response pairs in HAR format, allowing them to
+// be analyzed using tools built for browser devtools.
+//
+// It keeps the most recent entries only, up to a configurable maximum.
+// It must be created using [NewHARRecorder], and is safe for concurrent use.
+type HARRecorder struct {
+	maxEntries int
+	maxBody    int
+
+	mu      sync.Mutex
+	entries []harEntry
+	next    int
+}
+
+// NewHARRecorder creates a new [HARRecorder] that keeps up to maxEntries
+// entries, and records up to maxBody bytes of each request and response
+// body.
+func NewHARRecorder(maxEntries, maxBody int) *HARRecorder {
+	return &HARRecorder{
+		maxEntries: maxEntries,
+		maxBody:    maxBody,
+		entries:    make([]harEntry, 0, maxEntries),
+	}
+}
+
+// WithHAR records the sampled requests using rec.
+// sampleRate is the fraction of requests to record, e.g. 0.01 to record
+// every hundredth request on average.
+//
+// Note that the recorded entries contain all headers, including credentials,
+// and should therefore only be written to a secure sink.
+func WithHAR(rec *HARRecorder, sampleRate float64) Option {
+	return func(c *config) {
+		c.har = rec
+		c.harSampleRate = sampleRate
+	}
+}
+
+// WriteTo writes a HAR document holding the recorded entries, oldest first,
+// to w.
+func (rec *HARRecorder) WriteTo(w io.Writer) (int64, error) {
+	rec.mu.Lock()
+	entries := make([]harEntry, 0, len(rec.entries))
+	entries = append(entries, rec.entries[rec.next:]...)
+	entries = append(entries, rec.entries[:rec.next]...)
+	rec.mu.Unlock()
+
+	doc := harDocument{Log: harLog{
+		Version: "1.2",
+		Creator: harCreator{Name: "chizap", Version: "1"},
+		Entries: entries,
+	}}
+
+	b, err := json.Marshal(doc)
+	if err != nil {
+		return 0, err
+	}
+
+	n, err := w.Write(b)
+	return int64(n), err
+}
+
+// sample reports whether a request should be recorded.
+func (rec *HARRecorder) sample(rate float64) bool {
+	return rate >= 1 || rand.Float64() < rate //nolint:gosec // no need for crypto/rand
+}
+
+func (rec *HARRecorder) add(e harEntry) {
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+
+	if len(rec.entries) < rec.maxEntries {
+		rec.entries = append(rec.entries, e)
+		return
+	}
+
+	if rec.maxEntries == 0 {
+		return
+	}
+
+	rec.entries[rec.next] = e
+	rec.next = (rec.next + 1) % rec.maxEntries
+}
+
+// harCapture holds the data captured for a single HAR entry.
+type harCapture struct {
+	start    time.Time
+	reqBody  *bodyRecorder
+	respBody *limitedBuffer
+}
+
+func (rec *HARRecorder) capture(r *http.Request, ww middleware.WrapResponseWriter) *harCapture {
+	hc := &harCapture{
+		start:    time.Now(),
+		reqBody:  recordBody(r, rec.maxBody),
+		respBody: &limitedBuffer{limit: rec.maxBody},
+	}
+	ww.Tee(hc.respBody)
+	return hc
+}
+
+func (rec *HARRecorder) record(hc *harCapture, r *http.Request, ww middleware.WrapResponseWriter) {
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+
+	req := harRequest{
+		Method:      r.Method,
+		URL:         scheme + "://" + r.Host + r.URL.RequestURI(),
+		HTTPVersion: r.Proto,
+		Cookies:     []harNameValue{},
+		Headers:     harHeaders(r.Header),
+		QueryString: []harNameValue{},
+		HeadersSize: -1,
+		BodySize:    int(r.ContentLength),
+	}
+
+	for k, vals := range r.URL.Query() {
+		for _, v := range vals {
+			req.QueryString = append(req.QueryString, harNameValue{Name: k, Value: v})
+		}
+	}
+	for _, c := range r.Cookies() {
+		req.Cookies = append(req.Cookies, harNameValue{Name: c.Name, Value: c.Value})
+	}
+	if body := hc.reqBody.bytes(); body != nil {
+		req.PostData = &harPostData{MimeType: r.Header.Get("Content-Type"), Text: string(body)}
+	}
+
+	status := ww.Status()
+	if status == 0 {
+		status = http.StatusOK
+	}
+
+	resp := harResponse{
+		Status:      status,
+		StatusText:  http.StatusText(status),
+		HTTPVersion: r.Proto,
+		Cookies:     []harNameValue{},
+		Headers:     harHeaders(ww.Header()),
+		Content: harContent{
+			Size:     ww.BytesWritten(),
+			MimeType: ww.Header().Get("Content-Type"),
+			Text:     hc.respBody.String(),
+		},
+		RedirectURL: ww.Header().Get("Location"),
+		HeadersSize: -1,
+		BodySize:    ww.BytesWritten(),
+	}
+
+	lat := time.Since(hc.start)
+	ms := float64(lat) / float64(time.Millisecond)
+
+	rec.add(harEntry{
+		StartedDateTime: hc.start.Format(time.RFC3339Nano),
+		Time:            ms,
+		Request:         req,
+		Response:        resp,
+		Cache:           struct{}{},
+		Timings:         harTimings{Send: 0, Wait: ms, Receive: 0},
+	})
+}
+
+// limitedBuffer is an io.Writer that keeps up to limit bytes of what is
+// written to it, and discards the rest.
+type limitedBuffer struct {
+	limit int
+	buf   strings.Builder
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if remaining := b.limit - b.buf.Len(); remaining > 0 {
+		if len(p) > remaining {
+			b.buf.Write(p[:remaining])
+		} else {
+			b.buf.Write(p)
+		}
+	}
+
+	return len(p), nil
+}
+
+func (b *limitedBuffer) String() string {
+	return b.buf.String()
+}
+
+func harHeaders(h http.Header) []harNameValue {
+	headers := make([]harNameValue, 0, len(h))
+	for k, vals := range h {
+		for _, v := range vals {
+			headers = append(headers, harNameValue{Name: k, Value: v})
+		}
+	}
+
+	return headers
+}
+
+type (
+	harDocument struct {
+		Log harLog `json:"log"`
+	}
+
+	harLog struct {
+		Version string     `json:"version"`
+		Creator harCreator `json:"creator"`
+		Entries []harEntry `json:"entries"`
+	}
+
+	harCreator struct {
+		Name    string `json:"name"`
+		Version string `json:"version"`
+	}
+
+	harEntry struct {
+		StartedDateTime string      `json:"startedDateTime"`
+		Time            float64     `json:"time"`
+		Request         harRequest  `json:"request"`
+		Response        harResponse `json:"response"`
+		Cache           struct{}    `json:"cache"`
+		Timings         harTimings  `json:"timings"`
+	}
+
+	harRequest struct {
+		Method      string         `json:"method"`
+		URL         string         `json:"url"`
+		HTTPVersion string         `json:"httpVersion"`
+		Cookies     []harNameValue `json:"cookies"`
+		Headers     []harNameValue `json:"headers"`
+		QueryString []harNameValue `json:"queryString"`
+		PostData    *harPostData   `json:"postData,omitempty"`
+		HeadersSize int            `json:"headersSize"`
+		BodySize    int            `json:"bodySize"`
+	}
+
+	harPostData struct {
+		MimeType string `json:"mimeType"`
+		Text     string `json:"text"`
+	}
+
+	harResponse struct {
+		Status      int            `json:"status"`
+		StatusText  string         `json:"statusText"`
+		HTTPVersion string         `json:"httpVersion"`
+		Cookies     []harNameValue `json:"cookies"`
+		Headers     []harNameValue `json:"headers"`
+		Content     harContent     `json:"content"`
+		RedirectURL string         `json:"redirectURL"`
+		HeadersSize int            `json:"headersSize"`
+		BodySize    int            `json:"bodySize"`
+	}
+
+	harContent struct {
+		Size     int    `json:"size"`
+		MimeType string `json:"mimeType"`
+		Text     string `json:"text,omitempty"`
+	}
+
+	harNameValue struct {
+		Name  string `json:"name"`
+		Value string `json:"value"`
+	}
+
+	harTimings struct {
+		Send    float64 `json:"send"`
+		Wait    float64 `json:"wait"`
+		Receive float64 `json:"receive"`
+	}
+)
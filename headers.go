@@ -0,0 +1,139 @@
+package chizap
+
+import (
+	"net/http"
+	"strings"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// WithHeaders adds a headers object to both the logger saved in the request
+// context and the completion entry, holding the values of the passed request
+// headers, if set.
+//
+// Headers with a single value are logged as strings, and headers with
+// multiple values as arrays of strings.
+func WithHeaders(headers ...string) Option {
+	return func(c *config) {
+		c.headers = append(c.headers, headers...)
+	}
+}
+
+// WithResponseHeaders adds a response_headers object to the completion
+// entry, holding the values of the passed response headers, if set.
+//
+// Headers are logged in the same way as by [WithHeaders].
+func WithResponseHeaders(headers ...string) Option {
+	return func(c *config) {
+		c.responseHeaders = append(c.responseHeaders, headers...)
+	}
+}
+
+// WithTrailers adds a trailers object to the completion entry, holding the
+// values of the passed HTTP trailers, if written by the handler.
+// If no trailers are passed, all trailers written by the handler are logged.
+//
+// Trailers are logged in the same way headers are logged by [WithHeaders].
+func WithTrailers(trailers ...string) Option {
+	return func(c *config) {
+		c.trailers = true
+		c.trailerNames = append(c.trailerNames, trailers...)
+	}
+}
+
+// headerObject is a zapcore.ObjectMarshaler that logs the values of the
+// headers named in keys, using the names as keys.
+// The values are sanitized using sanitize, if set.
+type headerObject struct {
+	h        http.Header
+	keys     []string
+	sanitize func(string) string
+}
+
+func headerField(key string, h http.Header, keys []string, sanitize func(string) string) zap.Field {
+	return zap.Object(key, headerObject{h: h, keys: keys, sanitize: sanitize})
+}
+
+func (o headerObject) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	for _, k := range o.keys {
+		vals := o.h.Values(k)
+		switch len(vals) {
+		case 0:
+		case 1:
+			enc.AddString(k, sanitizeWith(o.sanitize, vals[0]))
+		default:
+			if err := enc.AddArray(k, sanitizedArray(vals, o.sanitize)); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+type stringArray []string
+
+// sanitizedArray returns vals as a stringArray, sanitizing each value using
+// sanitize, if set.
+func sanitizedArray(vals []string, sanitize func(string) string) stringArray {
+	if sanitize == nil {
+		return vals
+	}
+
+	a := make(stringArray, len(vals))
+	for i, v := range vals {
+		a[i] = sanitize(v)
+	}
+
+	return a
+}
+
+func (a stringArray) MarshalLogArray(enc zapcore.ArrayEncoder) error {
+	for _, s := range a {
+		enc.AppendString(s)
+	}
+
+	return nil
+}
+
+// trailerHeader returns the trailers written to h, keyed by their canonical
+// name.
+//
+// If names is empty, all declared trailers and all trailers set using
+// [http.TrailerPrefix] are returned.
+// Otherwise, only the named ones are.
+func trailerHeader(h http.Header, names []string) (http.Header, []string) {
+	if len(names) == 0 {
+		for _, declared := range h.Values("Trailer") {
+			for _, name := range strings.Split(declared, ",") {
+				if name = strings.TrimSpace(name); name != "" {
+					names = append(names, name)
+				}
+			}
+		}
+
+		for k := range h {
+			if strings.HasPrefix(k, http.TrailerPrefix) {
+				names = append(names, strings.TrimPrefix(k, http.TrailerPrefix))
+			}
+		}
+	}
+
+	trailers := make(http.Header, len(names))
+	keys := make([]string, 0, len(names))
+	for _, name := range names {
+		name = http.CanonicalHeaderKey(name)
+		if _, ok := trailers[name]; ok {
+			continue
+		}
+
+		vals := append(h.Values(name), h.Values(http.TrailerPrefix+name)...)
+		if len(vals) > 0 {
+			trailers[name] = vals
+			keys = append(keys, name)
+		}
+	}
+
+	return trailers, keys
+}
@@ -0,0 +1,42 @@
+package chizap
+
+import (
+	"context"
+	"net/http"
+
+	"go.uber.org/zap"
+)
+
+// Healthz returns a handler for health check endpoints, such as /healthz or
+// /readyz, whose requests are never logged by the middleware returned by
+// [New], regardless of its excluded paths:
+//
+//	r.Get("/healthz", chizap.Healthz())
+//	r.Get("/readyz", chizap.Healthz(db.PingContext))
+//
+// The handler responds with status 200 and body ok, if all the passed checks
+// succeed.
+// Otherwise, it responds with status 503 and body unavailable, and logs the
+// error of the first check that failed at warn level, using the logger
+// returned by [FromContext], so that it isn't exposed to clients.
+func Healthz(checks ...func(ctx context.Context) error) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if s := getState(r); s != nil {
+			s.excluded = true
+		}
+
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+
+		for _, check := range checks {
+			if err := check(r.Context()); err != nil {
+				FromContext(r.Context()).Warn("health check failed", zap.Error(err))
+
+				w.WriteHeader(http.StatusServiceUnavailable)
+				_, _ = w.Write([]byte("unavailable"))
+				return
+			}
+		}
+
+		_, _ = w.Write([]byte("ok"))
+	}
+}
@@ -0,0 +1,141 @@
+package chizap
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// DefaultLatencyBuckets are the upper bounds of the buckets used by
+// [NewLatencyHistograms], if no buckets are passed.
+var DefaultLatencyBuckets = []time.Duration{
+	5 * time.Millisecond,
+	10 * time.Millisecond,
+	25 * time.Millisecond,
+	50 * time.Millisecond,
+	100 * time.Millisecond,
+	250 * time.Millisecond,
+	500 * time.Millisecond,
+	time.Second,
+	2500 * time.Millisecond,
+	5 * time.Second,
+	10 * time.Second,
+}
+
+// LatencyHistograms is an [Observer] that maintains a latency histogram per
+// route, which can be logged on demand or on shutdown, so that
+// post-incident analysis has distribution data even without a metrics
+// backend:
+//
+//	h := chizap.NewLatencyHistograms()
+//	r.Use(chizap.New(l, chizap.WithObserver(h)))
+//
+//	// on shutdown
+//	h.Log(l)
+//
+// It must be created using [NewLatencyHistograms].
+type LatencyHistograms struct {
+	buckets []time.Duration
+
+	mu     sync.Mutex
+	routes map[string][]uint64
+}
+
+var _ Observer = (*LatencyHistograms)(nil)
+
+// NewLatencyHistograms creates new [LatencyHistograms] using the passed
+// bucket upper bounds, or, if none are passed, [DefaultLatencyBuckets].
+// Latencies exceeding the largest bound are counted in an additional +Inf
+// bucket.
+func NewLatencyHistograms(buckets ...time.Duration) *LatencyHistograms {
+	if len(buckets) == 0 {
+		buckets = DefaultLatencyBuckets
+	}
+
+	buckets = append([]time.Duration(nil), buckets...)
+	sort.Slice(buckets, func(i, j int) bool { return buckets[i] < buckets[j] })
+
+	return &LatencyHistograms{buckets: buckets, routes: make(map[string][]uint64)}
+}
+
+// ObserveCompletion implements [Observer].
+func (h *LatencyHistograms) ObserveCompletion(c Completion) {
+	route := c.Route
+	if route == "" {
+		route = templatePath(c.Request.URL.Path)
+	}
+
+	i := sort.Search(len(h.buckets), func(i int) bool { return c.Latency <= h.buckets[i] })
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	counts, ok := h.routes[route]
+	if !ok {
+		counts = make([]uint64, len(h.buckets)+1)
+		h.routes[route] = counts
+	}
+	counts[i]++
+}
+
+// Log logs the histogram of every route at info level using l, one entry per
+// route, sorted by route.
+//
+// Each entry holds the following fields:
+//   - route: the route
+//   - requests: the number of requests
+//   - buckets: an object mapping the upper bound of each bucket, e.g. 250ms
+//     or +Inf, to the number of requests in that bucket
+func (h *LatencyHistograms) Log(l *zap.Logger) {
+	h.mu.Lock()
+	routes := make(map[string][]uint64, len(h.routes))
+	for route, counts := range h.routes {
+		routes[route] = append([]uint64(nil), counts...)
+	}
+	h.mu.Unlock()
+
+	keys := make([]string, 0, len(routes))
+	for route := range routes {
+		keys = append(keys, route)
+	}
+	sort.Strings(keys)
+
+	for _, route := range keys {
+		counts := routes[route]
+
+		var requests uint64
+		for _, n := range counts {
+			requests += n
+		}
+
+		l.Info("latency histogram",
+			zap.String("route", route),
+			zap.Uint64("requests", requests),
+			zap.Object("buckets", zapcore.ObjectMarshalerFunc(func(enc zapcore.ObjectEncoder) error {
+				for i, n := range counts {
+					enc.AddUint64(h.bucketName(i), n)
+				}
+				return nil
+			})),
+		)
+	}
+}
+
+// Reset discards all observations.
+func (h *LatencyHistograms) Reset() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.routes = make(map[string][]uint64)
+}
+
+func (h *LatencyHistograms) bucketName(i int) string {
+	if i == len(h.buckets) {
+		return "+Inf"
+	}
+
+	return h.buckets[i].String()
+}
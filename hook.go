@@ -0,0 +1,61 @@
+package chizap
+
+import (
+	"net/http"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// A CompletionHook is called with every completion entry the middleware
+// creates, after it was passed to the logger.
+//
+// fields holds all fields of the entry, i.e. the fields of the logger saved
+// in the request context, followed by the fields of the completion entry.
+// It must not be modified.
+type CompletionHook func(r *http.Request, e zapcore.Entry, fields []zap.Field)
+
+// WithCompletionHook adds a [CompletionHook] to the middleware, allowing
+// completion entries to be forwarded to other systems.
+//
+// Hooks are called synchronously, after the entry was passed to the logger.
+// If [WithAsync] is used, the entry is only queued at that point, and may
+// still be dropped.
+//
+// Hooks are also called for entries the logger doesn't write, e.g. because
+// their level is disabled, or because they were sampled out or suppressed
+// using [WithDedup], so that they can be forwarded independently of the
+// logger's configuration.
+// Hooks are not called for requests excluded from logging, e.g. using
+// [WithExcludedPaths].
+func WithCompletionHook(h CompletionHook) Option {
+	return func(c *config) {
+		c.completionHooks = append(c.completionHooks, h)
+	}
+}
+
+// A PanicHook is called by [Recoverer] for every panic it recovers from,
+// with the recovered value and the stack trace of the panic.
+type PanicHook func(r *http.Request, rec any, stack []byte)
+
+// WithPanicHook adds a [PanicHook] that [Recoverer] calls after logging a
+// panic, e.g. to report it to an error tracker.
+//
+// Panics caused by broken connections are not passed to the hook.
+func WithPanicHook(h PanicHook) Option {
+	return func(c *config) {
+		c.panicHooks = append(c.panicHooks, h)
+	}
+}
+
+// An ErrorHook is called for every error attached to a request using
+// [Error].
+type ErrorHook func(r *http.Request, err error)
+
+// WithErrorHook adds an [ErrorHook] that [Error] calls with the request and
+// the error attached to it, e.g. to report it to an error tracker.
+func WithErrorHook(h ErrorHook) Option {
+	return func(c *config) {
+		c.errorHooks = append(c.errorHooks, h)
+	}
+}
@@ -0,0 +1,63 @@
+package chizap
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+// WithJWTClaims adds the passed claims of the JWT bearer token of the request
+// to both the logger saved in the request context and the completion entry.
+//
+// The claims are logged as the jwt field, alongside a jwt_unverified field
+// set to true.
+// This is because the token is decoded on a best-effort basis, without
+// verifying its signature, and therefore must not be trusted.
+// Its purpose is solely to identify the caller in logs, even if
+// authentication happens in a later layer.
+//
+// If the request has no bearer token, the token is malformed, or it contains
+// none of the passed claims, both fields are omitted.
+func WithJWTClaims(claims ...string) Option {
+	return func(c *config) {
+		c.jwtClaims = append(c.jwtClaims, claims...)
+	}
+}
+
+func appendJWTFields(fields []zap.Field, r *http.Request, claims []string) []zap.Field {
+	scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return fields
+	}
+
+	parts := strings.Split(strings.TrimSpace(token), ".")
+	if len(parts) != 3 {
+		return fields
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+	if err != nil {
+		return fields
+	}
+
+	var all map[string]any
+	if err := json.Unmarshal(payload, &all); err != nil {
+		return fields
+	}
+
+	selected := make(map[string]any, len(claims))
+	for _, name := range claims {
+		if v, ok := all[name]; ok {
+			selected[name] = v
+		}
+	}
+
+	if len(selected) == 0 {
+		return fields
+	}
+
+	return append(fields, zap.Any("jwt", selected), zap.Bool("jwt_unverified", true))
+}
@@ -0,0 +1,86 @@
+package chizap
+
+import (
+	"os"
+	"strings"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+const serviceAccountNamespaceFile = "/var/run/secrets/kubernetes.io/serviceaccount/namespace"
+
+// WithKubernetesInfo adds a k8s object to every completion entry, holding the
+// pod, namespace, and node the service runs on.
+//
+// The values are read once, when the option is created, from the following
+// sources, of which the first non-empty one is used:
+//   - pod: the POD_NAME and HOSTNAME environment variables
+//   - namespace: the POD_NAMESPACE environment variable, and the namespace
+//     file of the mounted service account
+//   - node: the NODE_NAME environment variable
+//
+// POD_NAME, POD_NAMESPACE, and NODE_NAME are expected to be set using the
+// downward API, e.g.:
+//
+//	env:
+//	  - name: POD_NAME
+//	    valueFrom:
+//	      fieldRef:
+//	        fieldPath: metadata.name
+//
+// If none of the values are available, the option is a no-op.
+func WithKubernetesInfo() Option {
+	o := k8sObject{
+		pod:       firstNonEmpty(os.Getenv("POD_NAME"), os.Getenv("HOSTNAME")),
+		namespace: firstNonEmpty(os.Getenv("POD_NAMESPACE"), readTrimmed(serviceAccountNamespaceFile)),
+		node:      os.Getenv("NODE_NAME"),
+	}
+
+	if o == (k8sObject{}) {
+		return WithStaticFields()
+	}
+
+	return WithStaticFields(zap.Object("k8s", o))
+}
+
+type k8sObject struct {
+	pod       string
+	namespace string
+	node      string
+}
+
+func (o k8sObject) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	if o.pod != "" {
+		enc.AddString("pod", o.pod)
+	}
+	if o.namespace != "" {
+		enc.AddString("namespace", o.namespace)
+	}
+	if o.node != "" {
+		enc.AddString("node", o.node)
+	}
+
+	return nil
+}
+
+func firstNonEmpty(vals ...string) string {
+	for _, v := range vals {
+		if v != "" {
+			return v
+		}
+	}
+
+	return ""
+}
+
+// readTrimmed returns the whitespace-trimmed content of the file with the
+// passed name, or an empty string if it can't be read.
+func readTrimmed(name string) string {
+	b, err := os.ReadFile(name)
+	if err != nil {
+		return ""
+	}
+
+	return strings.TrimSpace(string(b))
+}
@@ -0,0 +1,71 @@
+package chizap
+
+import (
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// LatencyUnit determines how the latency field is encoded.
+type LatencyUnit uint8
+
+const (
+	// ZapDuration logs the latency using [zap.Duration], so that it is
+	// encoded as configured by the encoder's EncodeDuration.
+	// This is the default.
+	ZapDuration LatencyUnit = iota
+	// Milliseconds logs the latency as a float of milliseconds.
+	Milliseconds
+	// Microseconds logs the latency as an integer of microseconds.
+	Microseconds
+	// DualLatency logs the latency using [zap.Duration], like ZapDuration,
+	// and additionally adds a latency_ns field holding the latency as an
+	// integer of nanoseconds, suitable for querying and aggregation.
+	//
+	// Other durations, e.g. queue_time, are logged as if ZapDuration was
+	// used.
+	DualLatency
+)
+
+// WithLatencyUnit sets the [LatencyUnit] used to encode the latency field.
+func WithLatencyUnit(u LatencyUnit) Option {
+	return func(c *config) {
+		c.latencyUnit = u
+	}
+}
+
+// WithHumanLatency adds a latency_human field to the completion entry,
+// holding the latency in a human-readable form, e.g. 1.5ms.
+func WithHumanLatency() Option {
+	return func(c *config) {
+		c.humanLatency = true
+	}
+}
+
+// WithStartTime adds a start_time field to the completion entry, holding the
+// time the request arrived at the middleware, formatted using
+// [time.RFC3339Nano].
+//
+// Unlike the entry's own timestamp, it isn't affected by the time it took to
+// handle the request, or by delays in emitting the entry.
+func WithStartTime() Option {
+	return func(c *config) {
+		c.startTime = true
+	}
+}
+
+func latencyField(lat time.Duration, u LatencyUnit) zap.Field {
+	return durationField("latency", lat, u)
+}
+
+// durationField returns a field holding d, encoded using u.
+func durationField(key string, d time.Duration, u LatencyUnit) zap.Field {
+	switch u {
+	case Milliseconds:
+		return zap.Float64(key, float64(d)/float64(time.Millisecond))
+	case Microseconds:
+		return zap.Int64(key, d.Microseconds())
+	default:
+		return zap.Duration(key, d)
+	}
+}
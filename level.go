@@ -0,0 +1,32 @@
+package chizap
+
+import (
+	"net/http"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// WithLevel sets the function used to determine the level of the completion
+// entry, based on the response's status code.
+//
+// By default, all completion entries are logged at info level.
+func WithLevel(f func(status int) zapcore.Level) Option {
+	return func(c *config) {
+		c.level = f
+	}
+}
+
+// StatusLevel is a level function for use with [WithLevel].
+//
+// It returns [zapcore.ErrorLevel] for 5xx statuses, [zapcore.WarnLevel] for
+// 4xx statuses, and [zapcore.InfoLevel] for all other statuses.
+func StatusLevel(status int) zapcore.Level {
+	switch {
+	case status >= http.StatusInternalServerError:
+		return zapcore.ErrorLevel
+	case status >= http.StatusBadRequest:
+		return zapcore.WarnLevel
+	default:
+		return zapcore.InfoLevel
+	}
+}
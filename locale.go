@@ -0,0 +1,71 @@
+package chizap
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// WithLocale adds a locale field to the completion entry, holding the
+// primary locale of the client, i.e. the language tag with the highest
+// quality in the Accept-Language header, e.g. de-DE for
+// de-DE,de;q=0.9,en;q=0.8.
+//
+// If the header is missing, or only holds the wildcard, the field is
+// omitted.
+func WithLocale() Option {
+	return func(c *config) {
+		c.locale = true
+	}
+}
+
+// primaryLocale returns the language tag with the highest quality in the
+// Accept-Language header of r, or an empty string, if there is none.
+func primaryLocale(r *http.Request) string {
+	var best string
+	bestQ := 0.0
+
+	for _, h := range r.Header.Values("Accept-Language") {
+		for _, lang := range strings.Split(h, ",") {
+			tag, params, _ := strings.Cut(lang, ";")
+			tag = strings.TrimSpace(tag)
+			if tag == "" || tag == "*" {
+				continue
+			}
+
+			q := 1.0
+			if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+				var err error
+				if q, err = strconv.ParseFloat(v, 64); err != nil {
+					continue
+				}
+			}
+
+			if q > bestQ {
+				best, bestQ = canonicalLocale(tag), q
+			}
+		}
+	}
+
+	return best
+}
+
+// canonicalLocale formats tag using the conventional casing, e.g. en-US
+// for EN-us, and zh-Hant-TW for zh-hant-tw.
+func canonicalLocale(tag string) string {
+	subtags := strings.Split(tag, "-")
+	for i, st := range subtags {
+		switch {
+		case i == 0:
+			subtags[i] = strings.ToLower(st)
+		case len(st) == 2:
+			subtags[i] = strings.ToUpper(st)
+		case len(st) == 4:
+			subtags[i] = strings.ToUpper(st[:1]) + strings.ToLower(st[1:])
+		default:
+			subtags[i] = strings.ToLower(st)
+		}
+	}
+
+	return strings.Join(subtags, "-")
+}
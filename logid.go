@@ -0,0 +1,89 @@
+package chizap
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// WithLogIDHeader sets the response header with the passed name on responses
+// with a 4xx or 5xx status, so that support teams can get from a
+// customer-reported error straight to the log entry.
+//
+// The value of the header is the result of calling value with the request
+// ID, or, if value is nil, the request ID itself.
+// value can be used to, e.g. return a URL searching for the request ID in
+// the log management system.
+//
+// Requests without a request ID are not given the header.
+func WithLogIDHeader(name string, value func(requestID string) string) Option {
+	return func(c *config) {
+		c.logIDHeader = name
+		c.logIDValue = value
+	}
+}
+
+// logIDWriter sets the header configured using WithLogIDHeader, before
+// writing an error status.
+//
+// It must be wrapped using preserveCapabilities.
+type logIDWriter struct {
+	middleware.WrapResponseWriter
+	r           *http.Request
+	name        string
+	value       func(requestID string) string
+	wroteHeader bool
+}
+
+func (w *logIDWriter) WriteHeader(status int) {
+	if !w.wroteHeader {
+		w.wroteHeader = true
+
+		if status >= http.StatusBadRequest {
+			if id := middleware.GetReqID(w.r.Context()); id != "" {
+				if w.value != nil {
+					id = w.value(id)
+				}
+				w.Header().Set(w.name, id)
+			}
+		}
+	}
+
+	w.WrapResponseWriter.WriteHeader(status)
+}
+
+func (w *logIDWriter) Write(b []byte) (int, error) {
+	w.wroteHeader = true
+	return w.WrapResponseWriter.Write(b)
+}
+
+func (w *logIDWriter) ReadFrom(r io.Reader) (int64, error) {
+	w.wroteHeader = true
+	return readFrom(w.WrapResponseWriter, r)
+}
+
+func (w *logIDWriter) Flush() {
+	if f, ok := w.WrapResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (w *logIDWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	if h, ok := w.WrapResponseWriter.(http.Hijacker); ok {
+		return h.Hijack()
+	}
+
+	return nil, nil, errors.New("chizap: response writer does not implement http.Hijacker")
+}
+
+func (w *logIDWriter) Push(target string, opts *http.PushOptions) error {
+	if p, ok := w.WrapResponseWriter.(http.Pusher); ok {
+		return p.Push(target, opts)
+	}
+
+	return http.ErrNotSupported
+}
@@ -0,0 +1,81 @@
+package chizap
+
+import (
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"go.uber.org/zap"
+)
+
+// WithContentNegotiation adds the following fields to the completion entry,
+// helping to debug APIs that serve multiple representations:
+//   - accept: the Accept header of the request, omitted if empty
+//   - content_type: the Content-Type of the response, omitted if empty
+//   - negotiated: whether the content type of the response is acceptable
+//     according to the Accept header, always false for 406 responses, and
+//     omitted if the response has no content type and no 406 status
+func WithContentNegotiation() Option {
+	return func(c *config) {
+		c.contentNegotiation = true
+	}
+}
+
+func appendNegotiationFields(
+	fields []zap.Field, r *http.Request, ww middleware.WrapResponseWriter, clean func(string) string,
+) []zap.Field {
+	accept := strings.Join(r.Header.Values("Accept"), ", ")
+	if accept != "" {
+		fields = append(fields, zap.String("accept", clean(accept)))
+	}
+
+	ct := ww.Header().Get("Content-Type")
+	if ct != "" {
+		fields = append(fields, zap.String("content_type", clean(ct)))
+	}
+
+	switch {
+	case ww.Status() == http.StatusNotAcceptable:
+		fields = append(fields, zap.Bool("negotiated", false))
+	case ct != "":
+		fields = append(fields, zap.Bool("negotiated", isAcceptable(accept, ct)))
+	}
+
+	return fields
+}
+
+// isAcceptable reports whether the media type ct is acceptable according to
+// the passed Accept header value.
+func isAcceptable(accept, ct string) bool {
+	if accept == "" {
+		return true
+	}
+
+	mt, _, err := mime.ParseMediaType(ct)
+	if err != nil {
+		return false
+	}
+	typ, sub, _ := strings.Cut(mt, "/")
+
+	for _, mediaRange := range strings.Split(accept, ",") {
+		rangeType, params, err := mime.ParseMediaType(mediaRange)
+		if err != nil {
+			continue
+		}
+
+		if q, ok := params["q"]; ok {
+			if f, err := strconv.ParseFloat(q, 64); err == nil && f <= 0 {
+				continue
+			}
+		}
+
+		rt, rs, _ := strings.Cut(rangeType, "/")
+		if (rt == "*" || rt == typ) && (rs == "*" || rs == sub) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,50 @@
+package chizap
+
+import (
+	"net/http"
+	"time"
+)
+
+// Completion holds the measurements of a completed request.
+type Completion struct {
+	// Request is the completed request.
+	Request *http.Request
+	// Start is the time the request arrived at the middleware.
+	Start time.Time
+	// Route is the route pattern that matched the request, or an empty
+	// string if there is none.
+	Route string
+	// Status is the status code of the response.
+	Status int
+	// BytesWritten is the number of bytes written to the response body.
+	BytesWritten int
+	// Latency is the time it took to handle the request.
+	Latency time.Duration
+	// Panicked is true, if the handler panicked with a value other than
+	// [net/http.ErrAbortHandler], regardless of whether the panic was
+	// recovered by [Recoverer], another middleware, or not at all.
+	Panicked bool
+	// Err is the error attached using [Error], if any, or the join of all
+	// errors attached, if there are multiple.
+	Err error
+}
+
+// An Observer observes every request completed by the middleware, e.g. to
+// feed an anomaly detector or SLO monitor with the middleware's
+// measurements, without having to parse logs.
+//
+// ObserveCompletion is called concurrently, and must therefore be safe for
+// concurrent use.
+type Observer interface {
+	ObserveCompletion(c Completion)
+}
+
+// WithObserver adds an [Observer] to the middleware.
+//
+// It is called synchronously for all requests, except those excluded from
+// logging, regardless of whether their completion entry is actually written.
+func WithObserver(o Observer) Option {
+	return func(c *config) {
+		c.observers = append(c.observers, o)
+	}
+}
@@ -0,0 +1,505 @@
+package chizap
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// Option is a function that configures the middleware returned by [New].
+type Option func(*config)
+
+type config struct {
+	excludedPaths []string
+	excludedLevel *zapcore.Level
+
+	excludedSummaryInterval time.Duration
+	accessSummaryInterval   time.Duration
+
+	prioritySampling bool
+	lowPriorityRate  float64
+
+	shadowHeader string
+	shadowLogger *zap.Logger
+
+	deploymentSlot     string
+	deploymentSlotFunc func(r *http.Request) string
+
+	featureFlags func(ctx context.Context) []string
+	experiment   func(r *http.Request) (experiment, variant string)
+
+	locale             bool
+	contentNegotiation bool
+	conditional        bool
+	expectContinue     bool
+
+	suspicious    bool
+	maxHeaderSize int
+
+	tunnels bool
+
+	sanitize       func(string) string
+	sanitizerSet   bool
+	maxFieldLength int
+
+	withoutFields DefaultField
+
+	routes RouteFunc
+
+	recordSinks []RecordSink
+
+	throughput         bool
+	throughputMinBytes int
+
+	rangeFields bool
+	redirect    bool
+
+	cacheStatusHeaders []string
+
+	preflight PreflightMode
+
+	rateLimit bool
+
+	idempotencyKey     bool
+	hashIdempotencyKey bool
+
+	apiVersion func(r *http.Request) string
+
+	tenant func(r *http.Request) string
+
+	sessionHash    bool
+	sessionSalt    []byte
+	sessionCookies []string
+
+	auth              bool
+	authPrincipalType func(r *http.Request) string
+
+	jwtClaims []string
+
+	contextValues []contextValue
+
+	pathTemplating bool
+
+	queryParams []string
+
+	headers         []string
+	responseHeaders []string
+	trailers        bool
+	trailerNames    []string
+
+	connInfo bool
+
+	staticFields []zap.Field
+
+	pprofLabels bool
+	trace       bool
+
+	level       func(status int) zapcore.Level
+	dedupWindow time.Duration
+
+	asyncQueueSize int
+	asyncOnDrop    func(dropped uint64)
+
+	closer *Closer
+
+	strictOrdering bool
+
+	noContextLogger bool
+
+	dynamic *DynamicConfig
+
+	excludedContentTypes []string
+
+	aggInterval time.Duration
+	aggPrefixes []string
+
+	unmatchedLevel *zapcore.Level
+
+	panicStatus       int
+	panicHeader       http.Header
+	singleEntryPanics bool
+	panicBody         int
+
+	latencyUnit  LatencyUnit
+	humanLatency bool
+	startTime    bool
+
+	proxyHeaders bool
+	proxyChain   bool
+
+	cdn                *CDN
+	lbRequestIDHeaders []string
+
+	completionHooks []CompletionHook
+	panicHooks      []PanicHook
+	errorHooks      []ErrorHook
+
+	ipObservers []IPObserver
+	observers   []Observer
+
+	replayLogger  *zap.Logger
+	replayMaxBody int
+	replayCapture func(r *http.Request) bool
+
+	har           *HARRecorder
+	harSampleRate float64
+
+	reproCurl          bool
+	reproMaxBody       int
+	reproRedacted      map[string]struct{}
+	reproRedactedQuery map[string]struct{}
+
+	bodyHash bool
+
+	logIDHeader string
+	logIDValue  func(requestID string) string
+
+	// handlerNames caches the handler names determined by WithHandlerNames.
+	handlerNames *sync.Map
+}
+
+// WithExcludedPaths excludes all requests whose path starts with one of the
+// passed prefixes from being logged.
+//
+// Even if a path prefix is excluded, the logger will still be saved in the
+// request context.
+func WithExcludedPaths(prefixes ...string) Option {
+	return func(c *config) {
+		c.excludedPaths = append(c.excludedPaths, prefixes...)
+	}
+}
+
+// WithExcludedLevel logs the requests excluded using [WithExcludedPaths],
+// [Config.ExcludedPaths], or [Healthz] at the passed level, instead of
+// dropping them.
+//
+// Setting it to [zapcore.DebugLevel] allows operators to temporarily see,
+// e.g. health check traffic, by enabling debug logging, without
+// redeploying.
+func WithExcludedLevel(lvl zapcore.Level) Option {
+	return func(c *config) {
+		c.excludedLevel = &lvl
+	}
+}
+
+func (c *config) isExcluded(r *http.Request) bool {
+	for _, path := range c.excludedPaths {
+		if strings.HasPrefix(r.URL.Path, path) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// WithExcludeContentTypes excludes all requests whose response has a
+// Content-Type starting with one of the passed prefixes from being logged,
+// e.g. image/ or text/css.
+//
+// This prevents static assets served by the same router from drowning
+// application logs.
+func WithExcludeContentTypes(prefixes ...string) Option {
+	return func(c *config) {
+		c.excludedContentTypes = append(c.excludedContentTypes, prefixes...)
+	}
+}
+
+func (c *config) isExcludedContentType(h http.Header) bool {
+	if len(c.excludedContentTypes) == 0 {
+		return false
+	}
+
+	ct := h.Get("Content-Type")
+	for _, prefix := range c.excludedContentTypes {
+		if strings.HasPrefix(ct, prefix) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// WithThroughput adds a throughput_bps field to the completion entry of all
+// responses of which at least minBytes bytes were written.
+// It holds the number of bytes written per second of latency.
+//
+// Comparing the throughput of large responses helps telling slow clients
+// apart from slow handlers.
+func WithThroughput(minBytes int) Option {
+	return func(c *config) {
+		c.throughput = true
+		c.throughputMinBytes = minBytes
+	}
+}
+
+// WithRange adds fields describing range requests to the completion entry.
+//
+// If the request has a Range header, it is logged as the range field.
+// Additionally, the partial_content field reports whether the response had
+// status 206, in which case the response's Content-Range header is logged as
+// the content_range field.
+func WithRange() Option {
+	return func(c *config) {
+		c.rangeFields = true
+	}
+}
+
+// WithRedirect adds a redirect_to field to the completion entry of 3xx
+// responses, holding the value of the response's Location header.
+//
+// This helps diagnosing redirect loops and misconfigured canonical URLs.
+func WithRedirect() Option {
+	return func(c *config) {
+		c.redirect = true
+	}
+}
+
+// WithCacheStatus adds a cache_status field to the completion entry, holding
+// the value of the first of the passed response headers that is set.
+//
+// If no headers are passed, X-Cache and CF-Cache-Status are used.
+// If none of the headers is set, the field is omitted.
+func WithCacheStatus(headers ...string) Option {
+	if len(headers) == 0 {
+		headers = []string{"X-Cache", "CF-Cache-Status"}
+	}
+
+	return func(c *config) {
+		c.cacheStatusHeaders = headers
+	}
+}
+
+// PreflightMode determines how CORS preflight requests are logged.
+//
+// A request is considered a preflight request, if it is an OPTIONS request
+// with both an Origin and an Access-Control-Request-Method header.
+type PreflightMode uint8
+
+const (
+	// PreflightLog logs preflight requests like any other request.
+	// This is the default.
+	PreflightLog PreflightMode = iota
+	// PreflightDebug logs preflight requests using a single condensed entry
+	// at debug level, holding only the request_id, path, origin, and status
+	// fields, as well as a cors_preflight field set to true.
+	PreflightDebug
+	// PreflightSkip doesn't log preflight requests at all.
+	PreflightSkip
+)
+
+// WithPreflight sets the [PreflightMode] used for CORS preflight requests.
+//
+// Browser-heavy APIs may see a preflight request for almost every actual
+// request, doubling log volume if logged as usual.
+func WithPreflight(m PreflightMode) Option {
+	return func(c *config) {
+		c.preflight = m
+	}
+}
+
+// WithConditionalRequests adds the following fields to the completion
+// entries of conditional requests, i.e. those with an If-None-Match or
+// If-Modified-Since header, so that cache efficiency can be measured from the
+// logs:
+//   - conditional: always true
+//   - revalidated: whether the response was a 304, i.e. the client's cached
+//     copy is still valid
+func WithConditionalRequests() Option {
+	return func(c *config) {
+		c.conditional = true
+	}
+}
+
+// WithRateLimit adds fields describing rate limiting to the completion entry,
+// if the response has status 429, or a Retry-After or X-RateLimit-* header.
+//
+// The following fields are added:
+//   - rate_limited: whether the response has status 429
+//   - retry_after: the value of the Retry-After header, if set
+//   - rate_limit: an object holding the values of all X-RateLimit-* headers,
+//     keyed by the lowercased header name without the prefix, e.g. remaining
+//     for X-RateLimit-Remaining
+func WithRateLimit() Option {
+	return func(c *config) {
+		c.rateLimit = true
+	}
+}
+
+// WithIdempotencyKey adds an idempotency_key field to the completion entry,
+// holding the value of the Idempotency-Key request header, if set.
+//
+// This allows correlating retries of the same operation.
+//
+// If hashed is true, the hex-encoded SHA-256 hash of the key is logged
+// instead of the key itself.
+func WithIdempotencyKey(hashed bool) Option {
+	return func(c *config) {
+		c.idempotencyKey = true
+		c.hashIdempotencyKey = hashed
+	}
+}
+
+// WithTenantExtractor adds a tenant_id field, holding the tenant returned by
+// f, to both the logger saved in the request context and the completion
+// entry.
+// If f returns an empty string, the field is omitted.
+//
+// f is passed the request as it was before being handled, and hence can only
+// access data made available by preceding middlewares.
+// It may, however, only be called once the fields are needed, e.g. after the
+// handler returned.
+func WithTenantExtractor(f func(r *http.Request) string) Option {
+	return func(c *config) {
+		c.tenant = f
+	}
+}
+
+// WithSessionHash adds a session_hash field to both the logger saved in the
+// request context and the completion entry.
+// It holds the hex-encoded HMAC-SHA256 of the session credential, keyed with
+// the passed salt, allowing requests of the same session to be grouped
+// without ever logging the credential itself.
+//
+// The credential is the value of the first of the passed cookies that is set,
+// or, if none is, the credentials of the Authorization header.
+// If there is no credential, the field is omitted.
+//
+// The salt should be kept secret, as it otherwise allows verifying guessed
+// credentials against the logs.
+func WithSessionHash(salt []byte, cookies ...string) Option {
+	return func(c *config) {
+		c.sessionHash = true
+		c.sessionSalt = salt
+		c.sessionCookies = cookies
+	}
+}
+
+// WithAuth adds an auth object to the completion entry, describing how the
+// request was authenticated, without logging any secret material.
+//
+// The object holds the following fields:
+//   - scheme: the authentication scheme used, i.e. mtls, if the client
+//     presented a TLS certificate, otherwise the lowercased scheme of the
+//     Authorization header, e.g. bearer or basic, or none
+//   - principal_type: the type of the authenticated principal, e.g. user or
+//     service, as returned by principalType, omitted if empty
+//
+// principalType may be nil.
+func WithAuth(principalType func(r *http.Request) string) Option {
+	return func(c *config) {
+		c.auth = true
+		c.authPrincipalType = principalType
+	}
+}
+
+// WithStaticFields adds the passed fields to every completion entry.
+func WithStaticFields(fields ...zap.Field) Option {
+	return func(c *config) {
+		c.staticFields = append(c.staticFields, fields...)
+	}
+}
+
+// WithServiceInfo adds service, version, and commit fields to every
+// completion entry, so that logs identify the build that produced them, even
+// if aggregated across many services.
+//
+// Empty values are omitted.
+func WithServiceInfo(name, version, commit string) Option {
+	fields := make([]zap.Field, 0, 3)
+	if name != "" {
+		fields = append(fields, zap.String("service", name))
+	}
+	if version != "" {
+		fields = append(fields, zap.String("version", version))
+	}
+	if commit != "" {
+		fields = append(fields, zap.String("commit", commit))
+	}
+
+	return WithStaticFields(fields...)
+}
+
+// WithoutContextLogger stops the middleware from saving a logger in the
+// request context, for deployments that only need the completion entries.
+// This saves creating the logger and cloning the request's context.
+//
+// The completion entry still holds all fields of the context logger.
+// However, [Get] must not be used, and all other functions and middlewares
+// of this package that cooperate with the middleware, such as [Error] and
+// [MeasureUncompressed], become no-ops.
+// [Recoverer] falls back to the global logger.
+func WithoutContextLogger() Option {
+	return func(c *config) {
+		c.noContextLogger = true
+	}
+}
+
+// WithPanicStatus sets the status [Recoverer] responds with after recovering
+// from a panic.
+// By default, it responds with status 500.
+//
+// This is useful, e.g. for deployments in which load balancers should retry
+// elsewhere, rather than surfacing an error to the client.
+func WithPanicStatus(status int) Option {
+	return func(c *config) {
+		c.panicStatus = status
+	}
+}
+
+// panicStatusCode returns the status Recoverer responds with after
+// recovering from a panic.
+func (c *config) panicStatusCode() int {
+	if c.panicStatus != 0 {
+		return c.panicStatus
+	}
+
+	return http.StatusInternalServerError
+}
+
+// WithPanicHeader adds a header that [Recoverer] sets on the response, after
+// recovering from a panic, e.g. Retry-After.
+func WithPanicHeader(key, value string) Option {
+	return func(c *config) {
+		if c.panicHeader == nil {
+			c.panicHeader = make(http.Header)
+		}
+
+		c.panicHeader.Add(key, value)
+	}
+}
+
+// WithPanicBody includes up to maxBytes of the request body, as read by the
+// handler, in the entry [Recoverer] logs for a panic, by appending it to the
+// request field, so that panic-causing payloads can be reproduced.
+// Additionally, body_truncated is added, reporting whether the body exceeded
+// maxBytes.
+//
+// If [WithSingleEntryPanics] is used, the body is instead logged as the
+// request_body field of the completion entry.
+//
+// Note that the body may contain sensitive data, such as credentials.
+func WithPanicBody(maxBytes int) Option {
+	return func(c *config) {
+		c.panicBody = maxBytes
+	}
+}
+
+// WithSingleEntryPanics merges the entry [Recoverer] logs for a panic into
+// the completion entry, so that a panicking request is logged as a single
+// entry.
+//
+// The completion entry of a panicking request is then logged at error level,
+// and additionally holds the value the handler panicked with as the panic
+// field, and its stack trace as the stack field.
+// It is logged even if the request would otherwise be excluded or
+// aggregated.
+func WithSingleEntryPanics() Option {
+	return func(c *config) {
+		c.singleEntryPanics = true
+	}
+}
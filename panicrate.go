@@ -0,0 +1,143 @@
+package chizap
+
+import (
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// PanicRate holds the measurements of a route, whose panic rate exceeded the
+// threshold of a [PanicRateMonitor].
+type PanicRate struct {
+	// Route is the route pattern of the route, or its templated path, if the
+	// request wasn't matched by a route.
+	Route string
+	// Rate is the fraction of requests to the route that panicked.
+	Rate float64
+	// Requests is the number of requests to the route in the window, and
+	// Panics the number of those that panicked.
+	Requests int
+	Panics   int
+}
+
+// PanicRateMonitor is an [Observer] that computes the panic rate of every
+// route over a sliding window, and alerts if it exceeds a threshold, so that
+// automated mitigation, e.g. disabling a feature flag or paging on-call, can
+// be wired in-process.
+//
+// It must be created using [NewPanicRateMonitor].
+type PanicRateMonitor struct {
+	l           *zap.Logger
+	threshold   float64
+	window      time.Duration
+	minRequests int
+	onExceed    func(PanicRate)
+
+	mu     sync.Mutex
+	routes map[string]*routePanics
+}
+
+type routePanics struct {
+	counter  windowCounter
+	alerting bool
+}
+
+var _ Observer = (*PanicRateMonitor)(nil)
+
+// NewPanicRateMonitor creates a new [PanicRateMonitor] that alerts if more
+// than the fraction threshold of the requests to a route panicked within
+// window.
+// Routes with less than minRequests requests within window are never
+// alerted on.
+//
+// When alerting, the monitor logs an error using l, and calls onExceed, if it
+// is not nil.
+// Afterwards, it doesn't alert again for the route, until its panic rate
+// dropped to or below the threshold.
+//
+// onExceed is called synchronously, and should therefore return quickly.
+//
+// NewPanicRateMonitor panics, if threshold is not at least 0 and less than 1,
+// if window is too short to be divided into slots, or if minRequests is
+// negative.
+func NewPanicRateMonitor(
+	l *zap.Logger, threshold float64, window time.Duration, minRequests int, onExceed func(PanicRate),
+) *PanicRateMonitor {
+	switch {
+	case threshold < 0 || threshold >= 1:
+		panic("chizap: panic rate threshold must be at least 0 and less than 1")
+	case !validWindow(window):
+		panic("chizap: panic rate window must be at least " + (windowSlots * time.Nanosecond).String())
+	case minRequests < 0:
+		panic("chizap: minRequests must not be negative")
+	}
+
+	return &PanicRateMonitor{
+		l:           l,
+		threshold:   threshold,
+		window:      window,
+		minRequests: minRequests,
+		onExceed:    onExceed,
+		routes:      make(map[string]*routePanics),
+	}
+}
+
+// ObserveCompletion implements [Observer].
+func (m *PanicRateMonitor) ObserveCompletion(c Completion) {
+	route := c.Route
+	if route == "" {
+		route = templatePath(c.Request.URL.Path)
+	}
+
+	rate, alert := m.record(time.Now(), route, c.Panicked)
+	if !alert {
+		return
+	}
+
+	m.l.Error("panic rate exceeded",
+		zap.String("route", rate.Route),
+		zap.Float64("panic_rate", rate.Rate),
+		zap.Float64("panic_rate_threshold", m.threshold),
+		zap.Duration("window", m.window),
+		zap.Int("requests", rate.Requests),
+		zap.Int("panics", rate.Panics),
+	)
+
+	if m.onExceed != nil {
+		m.onExceed(rate)
+	}
+}
+
+// record records a request to route completed at now, and reports whether
+// the monitor should alert.
+func (m *PanicRateMonitor) record(now time.Time, route string, panicked bool) (PanicRate, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	rp, ok := m.routes[route]
+	if !ok {
+		rp = new(routePanics)
+		m.routes[route] = rp
+	}
+
+	rate := PanicRate{Route: route}
+	rate.Requests, rate.Panics = rp.counter.add(now, m.window, panicked)
+
+	if rate.Requests == 0 || rate.Requests < m.minRequests {
+		return rate, false
+	}
+
+	rate.Rate = float64(rate.Panics) / float64(rate.Requests)
+	if rate.Rate <= m.threshold {
+		rp.alerting = false
+		return rate, false
+	}
+
+	if rp.alerting {
+		return rate, false
+	}
+
+	rp.alerting = true
+	return rate, true
+}
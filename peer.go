@@ -0,0 +1,52 @@
+package chizap
+
+import (
+	"context"
+	"net/http"
+
+	"go.uber.org/zap"
+)
+
+type peerAddrKey struct{}
+
+// PreservePeerAddr is a middleware that preserves the remote address of the
+// request, i.e. the address of the socket peer, before it is rewritten by
+// middlewares like [github.com/go-chi/chi/v5/middleware.RealIP].
+//
+// It must be mounted before those middlewares:
+//
+//	r.Use(chizap.PreservePeerAddr)
+//	r.Use(middleware.RealIP)
+//	r.Use(chizap.Logger(l))
+//
+// If the remote address was rewritten, the following fields are added to both
+// the logger saved in the request context and the completion entry:
+//   - peer_addr: the original remote address, i.e. that of the socket peer,
+//     which usually is a proxy
+//   - client_ip: the IP of the resolved client, unless already resolved from
+//     the headers configured using [WithCDN] or [WithProxyHeaders]
+//
+// Since those options don't rewrite the remote address, the peer is
+// available as the remote field, when using them without RealIP.
+func PreservePeerAddr(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := context.WithValue(r.Context(), peerAddrKey{}, r.RemoteAddr)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+func appendPeerFields(fields []zap.Field, r *http.Request, haveClientIP bool) []zap.Field {
+	peer, _ := r.Context().Value(peerAddrKey{}).(string)
+	if peer == "" || peer == r.RemoteAddr {
+		return fields
+	}
+
+	fields = append(fields, zap.String("peer_addr", peer))
+	if !haveClientIP {
+		if ip := remoteIP(r.RemoteAddr); ip != "" {
+			fields = append(fields, zap.String("client_ip", ip))
+		}
+	}
+
+	return fields
+}
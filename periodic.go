@@ -0,0 +1,39 @@
+package chizap
+
+import (
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// flushPeriodically calls flush once per interval in a new goroutine.
+//
+// When cl is closed, the goroutine calls flush a final time, for the
+// current interval, stops, and l is synced.
+// If cl is nil, the goroutine runs for the remainder of the program.
+func flushPeriodically(l *zap.Logger, interval time.Duration, flush func(), cl *Closer) {
+	t := time.NewTicker(interval)
+	stop := make(chan struct{})
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+		defer t.Stop()
+
+		for {
+			select {
+			case <-t.C:
+				flush()
+			case <-stop:
+				flush()
+				return
+			}
+		}
+	}()
+
+	cl.add(func() error {
+		close(stop)
+		<-done
+		return l.Sync()
+	})
+}
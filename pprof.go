@@ -0,0 +1,43 @@
+package chizap
+
+import (
+	"context"
+	"net/http"
+	"runtime/pprof"
+
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// WithPprofLabels handles each request inside [pprof.Do], labeled with the
+// route, method, and request_id of the request.
+// This allows slicing CPU profiles by endpoint, and tying them back to the
+// logged requests.
+//
+// The route label holds the route pattern, as determined by the [RouteFunc]
+// set using [WithRoutes], if the middleware is mounted after routing, e.g.
+// using chi.Router.With, or the request path with all numeric and UUID
+// segments replaced by :id, otherwise.
+func WithPprofLabels() Option {
+	return func(c *config) {
+		c.pprofLabels = true
+	}
+}
+
+// labelProfiles wraps next, so that it handles requests inside [pprof.Do].
+func (c *config) labelProfiles(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		labels := pprof.Labels(
+			"route", c.routeName(r),
+			"method", r.Method,
+			"request_id", middleware.GetReqID(r.Context()),
+		)
+
+		pprof.Do(r.Context(), labels, func(ctx context.Context) {
+			// Keep the same *http.Request, so that modifications made by the
+			// handler, e.g. by http.ServeMux, remain visible to the
+			// middleware.
+			*r = *r.WithContext(ctx)
+			next.ServeHTTP(w, r)
+		})
+	})
+}
@@ -0,0 +1,101 @@
+package chizap
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// RequestPriority is the priority of a request, as used by
+// [WithPrioritySampling].
+type RequestPriority uint8
+
+const (
+	// PriorityNormal is the priority of requests without priority headers.
+	PriorityNormal RequestPriority = iota
+	// PriorityHigh is the priority of high-priority or critical requests.
+	PriorityHigh
+	// PriorityLow is the priority of bulk or background requests.
+	PriorityLow
+)
+
+// WithPrioritySampling makes the sampling decision consider the priority of
+// a request:
+//   - [PriorityHigh] requests are always logged, regardless of
+//     [Config.SampleRate]
+//   - [PriorityNormal] requests are sampled using [Config.SampleRate]
+//   - [PriorityLow] requests are sampled using lowRate, or
+//     [Config.SampleRate], if it is more aggressive
+//
+// lowRate is interpreted like [Config.SampleRate].
+//
+// The priority is determined using [PriorityOf].
+func WithPrioritySampling(lowRate float64) Option {
+	return func(c *config) {
+		c.prioritySampling = true
+		c.lowPriorityRate = lowRate
+	}
+}
+
+// PriorityOf returns the priority of r, as determined by its
+// X-Request-Priority header, which may be critical or high for
+// [PriorityHigh], and low, bulk, or background for [PriorityLow].
+//
+// If r has no such header, its RFC 9218 Priority header is used instead,
+// mapping urgencies 0 and 1 to [PriorityHigh], and 5 through 7 to
+// [PriorityLow].
+//
+// All other requests have [PriorityNormal].
+func PriorityOf(r *http.Request) RequestPriority {
+	switch strings.ToLower(strings.TrimSpace(r.Header.Get("X-Request-Priority"))) {
+	case "critical", "high":
+		return PriorityHigh
+	case "low", "bulk", "background":
+		return PriorityLow
+	}
+
+	for _, param := range strings.Split(r.Header.Get("Priority"), ",") {
+		k, v, ok := strings.Cut(strings.TrimSpace(param), "=")
+		if !ok || k != "u" {
+			continue
+		}
+
+		u, err := strconv.Atoi(v)
+		if err != nil {
+			return PriorityNormal
+		}
+
+		switch {
+		case u <= 1:
+			return PriorityHigh
+		case u >= 5:
+			return PriorityLow
+		default:
+			return PriorityNormal
+		}
+	}
+
+	return PriorityNormal
+}
+
+// sampledOut reports whether the completion entry of r should be dropped due
+// to sampling.
+func (c *config) sampledOut(r *http.Request, dyn *Config) bool {
+	if !c.prioritySampling {
+		return dyn.sampledOut()
+	}
+
+	switch PriorityOf(r) {
+	case PriorityHigh:
+		return false
+	case PriorityLow:
+		rate := c.lowPriorityRate
+		if rate <= 0 || (dyn.SampleRate > 0 && dyn.SampleRate < rate) {
+			rate = dyn.SampleRate
+		}
+
+		return (&Config{SampleRate: rate}).sampledOut()
+	default:
+		return dyn.sampledOut()
+	}
+}
@@ -0,0 +1,79 @@
+package chizap
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// WithQueryParams replaces the query field with a query_params object,
+// holding only the passed query parameters, if set.
+//
+// Values are logged typed, i.e. integers, floats, and booleans are logged as
+// such, and all other values as strings.
+// Parameters with multiple values are logged as arrays of strings.
+func WithQueryParams(params ...string) Option {
+	return func(c *config) {
+		c.queryParams = append(c.queryParams, params...)
+	}
+}
+
+// queryField returns the query field, or, if params is not empty, the
+// query_params field.
+// Values are sanitized using sanitize, if set.
+func queryField(r *http.Request, params []string, sanitize func(string) string) zap.Field {
+	if len(params) == 0 {
+		return zap.String("query", sanitizeWith(sanitize, r.URL.RawQuery))
+	}
+
+	q, _ := url.ParseQuery(r.URL.RawQuery)
+	return zap.Object("query_params", queryParamsObject{query: q, params: params, sanitize: sanitize})
+}
+
+type queryParamsObject struct {
+	query    url.Values
+	params   []string
+	sanitize func(string) string
+}
+
+func (o queryParamsObject) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	for _, p := range o.params {
+		vals, ok := o.query[p]
+		if !ok {
+			continue
+		}
+
+		if len(vals) != 1 {
+			if err := enc.AddArray(p, sanitizedArray(vals, o.sanitize)); err != nil {
+				return err
+			}
+			continue
+		}
+
+		addTyped(enc, p, sanitizeWith(o.sanitize, vals[0]))
+	}
+
+	return nil
+}
+
+// addTyped adds v to enc as an integer, float, or boolean, if it can be parsed
+// as such, or otherwise as a string.
+func addTyped(enc zapcore.ObjectEncoder, key, v string) {
+	if i, err := strconv.ParseInt(v, 10, 64); err == nil {
+		enc.AddInt64(key, i)
+		return
+	}
+	if f, err := strconv.ParseFloat(v, 64); err == nil {
+		enc.AddFloat64(key, f)
+		return
+	}
+	if b, err := strconv.ParseBool(v); err == nil {
+		enc.AddBool(key, b)
+		return
+	}
+
+	enc.AddString(key, v)
+}
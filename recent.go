@@ -0,0 +1,117 @@
+package chizap
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// Recent is a ring buffer keeping the last completion entries in memory, so
+// that they can be retrieved using its Handler, e.g. to see what just
+// happened on a machine, even if the central log pipeline is lagging.
+//
+// It must be created using [NewRecent], and added to a middleware using
+// [WithCompletionHook]:
+//
+//	rec := chizap.NewRecent(100)
+//	r.Use(chizap.New(l, chizap.WithCompletionHook(rec.Record)))
+//	r.Handle("/debug/requests", rec.Handler())
+//
+// Only entries that were written are kept, i.e. disabled levels or sampling
+// apply.
+type Recent struct {
+	mu      sync.Mutex
+	entries []map[string]any
+	next    int
+	size    int
+}
+
+// NewRecent creates a new [Recent] keeping the last n completion entries.
+func NewRecent(n int) *Recent {
+	return &Recent{size: n}
+}
+
+var (
+	// defaultRecent holds the completion entries kept using WithRecent.
+	defaultRecent     = new(Recent)
+	defaultRecentSize sync.Once
+)
+
+// WithRecent keeps the last n completion entries in a [Recent] shared by all
+// middlewares using WithRecent, which can be retrieved using
+// [RecentHandler].
+//
+// The size of the shared buffer is the n passed first.
+// Use [NewRecent] to give middlewares separate buffers.
+func WithRecent(n int) Option {
+	return func(c *config) {
+		defaultRecentSize.Do(func() {
+			defaultRecent.mu.Lock()
+			defaultRecent.size = n
+			defaultRecent.mu.Unlock()
+		})
+		c.completionHooks = append(c.completionHooks, defaultRecent.Record)
+	}
+}
+
+// Record is a [CompletionHook] keeping the completion entry.
+func (rb *Recent) Record(_ *http.Request, e zapcore.Entry, fields []zap.Field) {
+	enc := zapcore.NewMapObjectEncoder()
+	for _, f := range fields {
+		f.AddTo(enc)
+	}
+
+	enc.Fields["time"] = e.Time.Format(time.RFC3339Nano)
+	enc.Fields["level"] = e.Level.String()
+	enc.Fields["msg"] = e.Message
+
+	rb.mu.Lock()
+	defer rb.mu.Unlock()
+
+	if rb.size <= 0 {
+		return
+	}
+
+	if len(rb.entries) < rb.size {
+		rb.entries = append(rb.entries, enc.Fields)
+		return
+	}
+
+	rb.entries[rb.next] = enc.Fields
+	rb.next = (rb.next + 1) % rb.size
+}
+
+// ordered returns the kept entries, oldest first.
+// rb.mu must be held.
+func (rb *Recent) ordered() []map[string]any {
+	entries := make([]map[string]any, 0, len(rb.entries))
+	entries = append(entries, rb.entries[rb.next:]...)
+	return append(entries, rb.entries[:rb.next]...)
+}
+
+// Handler returns a handler that responds with the kept completion entries,
+// oldest first, encoded as a JSON array of objects.
+// Besides the entry's fields, each object holds the time, level, and msg of
+// the entry.
+//
+// Note that the entries contain all logged fields, and the handler should
+// therefore only be reachable by operators.
+func (rb *Recent) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rb.mu.Lock()
+		entries := rb.ordered()
+		rb.mu.Unlock()
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(entries)
+	})
+}
+
+// RecentHandler returns the Handler of the [Recent] used by [WithRecent].
+func RecentHandler() http.Handler {
+	return defaultRecent.Handler()
+}
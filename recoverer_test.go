@@ -0,0 +1,161 @@
+package chizap
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// captureStderr replaces stderr for the duration of the test, and returns
+// the buffer written to instead.
+func captureStderr(t *testing.T) *bytes.Buffer {
+	t.Helper()
+
+	var buf bytes.Buffer
+	orig := stderr
+	stderr = &buf
+	t.Cleanup(func() { stderr = orig })
+
+	return &buf
+}
+
+// panickingCore is a zapcore.Core that panics when writing entries at or
+// above error level.
+type panickingCore struct {
+	zapcore.Core
+}
+
+func (panickingCore) Enabled(zapcore.Level) bool { return true }
+
+func (c panickingCore) With([]zapcore.Field) zapcore.Core { return c }
+
+func (c panickingCore) Check(e zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	return ce.AddCore(e, c)
+}
+
+func (c panickingCore) Write(e zapcore.Entry, _ []zapcore.Field) error {
+	if e.Level >= zapcore.ErrorLevel {
+		panic("core panic")
+	}
+
+	return nil
+}
+
+func TestRecoverer_NestedPanic(t *testing.T) {
+	testCases := []struct {
+		name   string
+		logger *zap.Logger
+		opts   []Option
+		nested string
+	}{
+		{
+			name:   "panic hook",
+			logger: zap.NewNop(),
+			opts: []Option{
+				WithPanicHook(func(*http.Request, any, []byte) { panic("hook panic") }),
+			},
+			nested: "hook panic",
+		},
+		{
+			name:   "field extractor",
+			logger: zap.NewNop(),
+			opts: []Option{
+				WithTenantExtractor(func(*http.Request) string { panic("extractor panic") }),
+			},
+			nested: "extractor panic",
+		},
+		{
+			name:   "logger",
+			logger: zap.New(panickingCore{Core: zapcore.NewNopCore()}),
+			nested: "core panic",
+		},
+	}
+
+	for _, c := range testCases {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			out := captureStderr(t)
+
+			h := New(c.logger, c.opts...)(Recoverer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+				panic("handler panic")
+			})))
+
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+			if rec.Code != http.StatusInternalServerError {
+				t.Errorf("expected status %d, but got %d", http.StatusInternalServerError, rec.Code)
+			}
+
+			got := out.String()
+			for _, want := range []string{
+				"chizap: panic while recovering from panic: " + c.nested,
+				"original panic: handler panic",
+			} {
+				if !strings.Contains(got, want) {
+					t.Errorf("expected stderr to contain %q, but got:\n%s", want, got)
+				}
+			}
+		})
+	}
+}
+
+func TestNew_PanicStatus(t *testing.T) {
+	testCases := []struct {
+		name          string
+		recoverer     bool
+		expectStatus  int64
+		expectAborted bool
+	}{
+		{
+			name:         "recoverer",
+			recoverer:    true,
+			expectStatus: http.StatusInternalServerError,
+		},
+		{
+			name:          "no recoverer",
+			expectStatus:  0,
+			expectAborted: true,
+		},
+	}
+
+	for _, c := range testCases {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			core, logs := observer.New(zap.InfoLevel)
+
+			h := New(zap.New(core))(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+				panic("handler panic")
+			}))
+			if c.recoverer {
+				h = Recoverer(h)
+			}
+
+			func() {
+				defer func() { _ = recover() }()
+				h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+			}()
+
+			entries := logs.FilterMessage("GET /").All()
+			if len(entries) != 1 {
+				t.Fatalf("expected 1 completion entry, but got %d", len(entries))
+			}
+
+			fields := entries[0].ContextMap()
+			if fields["status"] != c.expectStatus {
+				t.Errorf("expected status %d, but got %v", c.expectStatus, fields["status"])
+			}
+
+			aborted, _ := fields["aborted"].(bool)
+			if aborted != c.expectAborted {
+				t.Errorf("expected aborted %t, but got %t", c.expectAborted, aborted)
+			}
+		})
+	}
+}
@@ -0,0 +1,54 @@
+package chizap
+
+import (
+	"net/http"
+	"net/http/httputil"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"go.uber.org/zap"
+)
+
+// WithReplayCapture captures the requests for which capture returns true, and
+// logs them to l in a replayable format, e.g. to reproduce hard-to-trigger
+// bugs.
+//
+// Each captured request is logged as an entry at info level, holding the
+// following fields:
+//   - request_id: the request ID, if set by
+//     [github.com/go-chi/chi/v5/middleware.RequestID]
+//   - replay: the request in HTTP/1.1 wire format, i.e. its request line,
+//     headers, and body, as read by the handler, up to maxBody bytes
+//   - body_truncated: whether the body exceeded maxBody bytes
+//
+// capture is called before the request is handled, and should be used to
+// limit capturing to, e.g. requests with a specific debug header, or of a
+// specific user.
+//
+// Note that the captured requests contain all headers, including
+// credentials, and should therefore only be logged to a secure sink.
+func WithReplayCapture(l *zap.Logger, maxBody int, capture func(r *http.Request) bool) Option {
+	return func(c *config) {
+		c.replayLogger = l
+		c.replayMaxBody = maxBody
+		c.replayCapture = capture
+	}
+}
+
+// logReplay logs r and the body recorded by body to l, truncating the body to
+// maxLen bytes, if maxLen is positive.
+func logReplay(l *zap.Logger, r *http.Request, body *bodyRecorder, maxLen int) {
+	dump, err := httputil.DumpRequest(r, false)
+	if err != nil {
+		l.Error("unable to dump request for replay", zap.Error(err))
+		return
+	}
+
+	b, truncated := truncateBytes(body.bytes(), maxLen)
+	dump = append(dump, b...)
+
+	l.Info(r.Method+" "+cleanPath(r)+" captured for replay",
+		zap.String("request_id", cleanRequestValue(r, middleware.GetReqID(r.Context()))),
+		zap.ByteString("replay", dump),
+		zap.Bool("body_truncated", truncated || body != nil && body.truncated),
+	)
+}
@@ -0,0 +1,51 @@
+package chizap
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// accountedWriter overrides the status and number of bytes reported by a
+// WrapResponseWriter with those actually sent to the client.
+type accountedWriter struct {
+	middleware.WrapResponseWriter
+	status int
+	bytes  int
+}
+
+func (w *accountedWriter) Status() int       { return w.status }
+func (w *accountedWriter) BytesWritten() int { return w.bytes }
+
+// accountResponse returns a WrapResponseWriter reporting the status and
+// number of bytes actually sent to the client, and whether net/http
+// suppressed the body written by the handler.
+//
+// The status of a response the handler didn't write a header for is
+// unwritten, which is 0, if no response is sent at all.
+//
+// The body of responses to HEAD requests is discarded by net/http, although
+// writing it succeeds, so WrapResponseWriter counts the bytes written by the
+// handler.
+// Writing the body of 1xx, 204, and 304 responses on the other hand fails,
+// so WrapResponseWriter already reports 0 bytes for them.
+func accountResponse(
+	r *http.Request, ww middleware.WrapResponseWriter, unwritten int,
+) (middleware.WrapResponseWriter, bool) {
+	status := ww.Status()
+	if status == 0 {
+		status = unwritten
+	}
+
+	bytes := ww.BytesWritten()
+	suppressed := bytes > 0 && r.Method == http.MethodHead
+	if suppressed {
+		bytes = 0
+	}
+
+	if status == ww.Status() && bytes == ww.BytesWritten() {
+		return ww, false
+	}
+
+	return &accountedWriter{WrapResponseWriter: ww, status: status, bytes: bytes}, suppressed
+}
@@ -0,0 +1,80 @@
+package chizap
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestNew_SuppressedBody(t *testing.T) {
+	testCases := []struct {
+		name           string
+		method         string
+		upgrade        bool
+		status         int // 0 to write nothing
+		expectStatus   int
+		expectBytes    int64
+		expectSuppress bool
+	}{
+		{name: "GET", method: http.MethodGet, status: http.StatusOK, expectStatus: http.StatusOK, expectBytes: 4},
+		{
+			name: "HEAD", method: http.MethodHead, status: http.StatusOK,
+			expectStatus: http.StatusOK, expectSuppress: true,
+		},
+		{name: "204", method: http.MethodGet, status: http.StatusNoContent, expectStatus: http.StatusNoContent},
+		{name: "304", method: http.MethodGet, status: http.StatusNotModified, expectStatus: http.StatusNotModified},
+		{name: "implicit upgrade", method: http.MethodGet, upgrade: true, expectStatus: http.StatusOK},
+	}
+
+	for _, c := range testCases {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			core, logs := observer.New(zap.InfoLevel)
+
+			srv := httptest.NewServer(New(zap.New(core))(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				if c.status != 0 {
+					w.WriteHeader(c.status)
+					_, _ = w.Write([]byte("body"))
+				}
+			})))
+			defer srv.Close()
+
+			req, err := http.NewRequest(c.method, srv.URL, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if c.upgrade {
+				req.Header.Set("Connection", "Upgrade")
+				req.Header.Set("Upgrade", "websocket")
+			}
+
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			_ = resp.Body.Close()
+			srv.Close() // wait for the completion entry
+
+			entries := logs.All()
+			if len(entries) != 1 {
+				t.Fatalf("expected 1 entry, but got %d", len(entries))
+			}
+
+			fields := entries[0].ContextMap()
+			if fields["status"] != int64(c.expectStatus) {
+				t.Errorf("expected status %d, but got %v", c.expectStatus, fields["status"])
+			}
+			if fields["bytes_written"] != c.expectBytes {
+				t.Errorf("expected bytes_written %d, but got %v", c.expectBytes, fields["bytes_written"])
+			}
+
+			suppressed, _ := fields["body_suppressed"].(bool)
+			if suppressed != c.expectSuppress {
+				t.Errorf("expected body_suppressed %t, but got %t", c.expectSuppress, suppressed)
+			}
+		})
+	}
+}
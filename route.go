@@ -0,0 +1,190 @@
+package chizap
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"go.uber.org/zap/zapcore"
+)
+
+// WithPathTemplating uses the route pattern instead of the raw request path
+// in the message of the completion entry, bounding its cardinality.
+//
+// If no route pattern is available, e.g. because no route matched, the
+// request path is normalized instead, by replacing all numeric and UUID
+// segments with :id.
+// That normalized path is then also logged as the route field.
+func WithPathTemplating() Option {
+	return func(c *config) {
+		c.pathTemplating = true
+	}
+}
+
+// WithUnmatchedLevel logs the completion entries of requests that didn't
+// match any route, e.g. because they fell through to chi's NotFound or
+// MethodNotAllowed handler, at the passed level, and adds a route_matched
+// field set to false to them.
+//
+// This prevents traffic of scanners probing for unknown paths from looking
+// like application errors.
+//
+// A request is considered unmatched, if no route pattern is available, or if
+// it has status 404 or 405, and the route pattern ends with /*, as is the
+// case when falling through to the NotFound handler of a mounted sub-router.
+// Route patterns are determined as configured using [WithRoutes].
+func WithUnmatchedLevel(lvl zapcore.Level) Option {
+	return func(c *config) {
+		c.unmatchedLevel = &lvl
+	}
+}
+
+// isUnmatched reports whether the request with the passed route pattern and
+// status didn't match any route.
+func isUnmatched(route string, status int) bool {
+	if route == "" {
+		return true
+	}
+
+	return (status == http.StatusNotFound || status == http.StatusMethodNotAllowed) &&
+		strings.HasSuffix(route, "/*")
+}
+
+// A RouteFunc returns the pattern of the route that matched r, or an empty
+// string, if no route matched, or the router that handled r doesn't expose
+// route patterns.
+//
+// RouteFuncs adapt the middleware to a router.
+// Besides chi, for which [ChiRoutes] is used, and [http.ServeMux], for which
+// [ServeMuxRoutes] is used, the submodule
+// [github.com/mavolin/chizap/chizapmux] adapts gorilla/mux.
+// Any other router exposing the matched route through the request can be
+// adapted the same way.
+type RouteFunc func(r *http.Request) string
+
+// WithRoutes sets the [RouteFunc] used to determine the route pattern of a
+// request, e.g. for the route field, [WithPathTemplating], or
+// [WithUnmatchedLevel].
+//
+// By default, [ChiRoutes] is used, falling back to [ServeMuxRoutes], as if
+// using:
+//
+//	WithRoutes(FirstRoute(ChiRoutes, ServeMuxRoutes))
+//
+// All other options are independent of the router.
+// The only exception is [WithHandlerNames], which requires chi.
+func WithRoutes(f RouteFunc) Option {
+	return func(c *config) {
+		c.routes = f
+	}
+}
+
+// FirstRoute returns a [RouteFunc] that returns the first non-empty route
+// pattern returned by fs, e.g. to share a configuration between services
+// using different routers.
+func FirstRoute(fs ...RouteFunc) RouteFunc {
+	return func(r *http.Request) string {
+		for _, f := range fs {
+			if route := f(r); route != "" {
+				return route
+			}
+		}
+
+		return ""
+	}
+}
+
+// ChiRoutes is a [RouteFunc] returning the chi route pattern that matched r.
+//
+// Note that the pattern is only complete once routing finished, i.e. it is
+// only available to the middleware after the handler returns, or, if the
+// middleware is mounted after routing, e.g. using chi.Router.With.
+func ChiRoutes(r *http.Request) string {
+	if rctx := chi.RouteContext(r.Context()); rctx != nil {
+		return rctx.RoutePattern()
+	}
+
+	return ""
+}
+
+// ServeMuxRoutes is a [RouteFunc] returning the pattern of the
+// [http.ServeMux] that matched r, without its method.
+//
+// Patterns are only available if built with Go 1.23 or later.
+func ServeMuxRoutes(r *http.Request) string {
+	return stdPattern(r)
+}
+
+// defaultRoutes is the RouteFunc used, if WithRoutes isn't used.
+var defaultRoutes = FirstRoute(ChiRoutes, ServeMuxRoutes)
+
+// routePattern returns the route pattern of the request, as determined by
+// the RouteFunc set using WithRoutes, or an empty string, if there is none.
+func (c *config) routePattern(r *http.Request) string {
+	if c.routes == nil {
+		return defaultRoutes(r)
+	}
+
+	return c.routes(r)
+}
+
+// routeName returns the route pattern of the request, or, if there is none,
+// the templated request path.
+func (c *config) routeName(r *http.Request) string {
+	if route := c.routePattern(r); route != "" {
+		return route
+	}
+
+	return templatePath(r.URL.Path)
+}
+
+// templatePath replaces all numeric and UUID segments of path with :id.
+func templatePath(path string) string {
+	segs := strings.Split(path, "/")
+	for i, seg := range segs {
+		if isNumeric(seg) || isUUID(seg) {
+			segs[i] = ":id"
+		}
+	}
+
+	return strings.Join(segs, "/")
+}
+
+func isNumeric(s string) bool {
+	if s == "" {
+		return false
+	}
+
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+
+	return true
+}
+
+func isUUID(s string) bool {
+	if len(s) != 36 {
+		return false
+	}
+
+	for i, c := range s {
+		switch i {
+		case 8, 13, 18, 23:
+			if c != '-' {
+				return false
+			}
+		default:
+			if !isHex(c) {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+func isHex(c rune) bool {
+	return (c >= '0' && c <= '9') || (c >= 'a' && c <= 'f') || (c >= 'A' && c <= 'F')
+}
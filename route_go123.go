@@ -0,0 +1,18 @@
+//go:build go1.23
+
+package chizap
+
+import (
+	"net/http"
+	"strings"
+)
+
+// stdPattern returns the [http.ServeMux] pattern that matched the request,
+// without its method, or an empty string if there is none.
+func stdPattern(r *http.Request) string {
+	if _, pattern, ok := strings.Cut(r.Pattern, " "); ok {
+		return strings.TrimLeft(pattern, " \t")
+	}
+
+	return r.Pattern
+}
@@ -0,0 +1,11 @@
+//go:build !go1.23
+
+package chizap
+
+import "net/http"
+
+// stdPattern returns an empty string, as http.Request.Pattern is only
+// available starting with Go 1.23.
+func stdPattern(*http.Request) string {
+	return ""
+}
@@ -0,0 +1,81 @@
+package chizap
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// WithSanitizer sets the function used to sanitize user-controlled values,
+// i.e. the path, query, user agent, referer, headers, and all other values
+// derived from the request target or headers, such as the request ID,
+// before they are logged.
+//
+// By default, [EscapeControlChars] is used, preventing log injection through
+// crafted request values, e.g. a path containing an encoded newline that
+// forges an additional entry when using a console encoder.
+// Passing nil disables sanitization.
+func WithSanitizer(f func(string) string) Option {
+	return func(c *config) {
+		c.sanitize = f
+		c.sanitizerSet = true
+	}
+}
+
+// EscapeControlChars escapes all control characters in s, including CR and
+// LF, as well as the Unicode line and paragraph separators, using Go escape
+// sequences, e.g. \n or \x00.
+func EscapeControlChars(s string) string {
+	if strings.IndexFunc(s, needsEscape) < 0 {
+		return s
+	}
+
+	var b strings.Builder
+	b.Grow(len(s) + 8)
+
+	for _, r := range s {
+		if !needsEscape(r) {
+			b.WriteRune(r)
+			continue
+		}
+
+		q := strconv.QuoteRuneToASCII(r)
+		b.WriteString(q[1 : len(q)-1])
+	}
+
+	return b.String()
+}
+
+func needsEscape(r rune) bool {
+	return unicode.IsControl(r) || r == '\u2028' || r == '\u2029'
+}
+
+// clean sanitizes s using the configured sanitizer.
+func (c *config) clean(s string) string {
+	return sanitizeWith(c.sanitize, s)
+}
+
+// sanitizeWith sanitizes s using f, or returns s unchanged, if f is nil.
+func sanitizeWith(f func(string) string, s string) string {
+	if f == nil {
+		return s
+	}
+
+	return f(s)
+}
+
+// cleanPath returns the path of r, sanitized using cleanRequestValue.
+func cleanPath(r *http.Request) string {
+	return cleanRequestValue(r, r.URL.Path)
+}
+
+// cleanRequestValue sanitizes v using the sanitizer of the middleware that
+// handled r, or using EscapeControlChars, if r wasn't handled by one.
+func cleanRequestValue(r *http.Request, v string) string {
+	if s := getState(r); s != nil {
+		return s.cfg.clean(v)
+	}
+
+	return EscapeControlChars(v)
+}
@@ -0,0 +1,18 @@
+package chizap
+
+import "go.uber.org/zap"
+
+// WithShadowTraffic marks the completion entries of requests that have the
+// passed header set to a non-empty value, e.g. X-Shadow-Traffic, with a
+// shadow field set to true, so that mirrored or load-test traffic can be
+// separated from production traffic in log analytics.
+//
+// If l is not nil, the completion entries of shadow requests are written to
+// l instead of the logger passed to [New].
+// The logger saved in the request context is unaffected.
+func WithShadowTraffic(header string, l *zap.Logger) Option {
+	return func(c *config) {
+		c.shadowHeader = header
+		c.shadowLogger = l
+	}
+}
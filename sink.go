@@ -0,0 +1,154 @@
+package chizap
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"go.uber.org/zap"
+)
+
+// RequestRecord is the structured record of a completed request, as passed
+// to a [RecordSink].
+type RequestRecord struct {
+	// Time is the time the request arrived at the middleware.
+	Time time.Time `json:"time"`
+	// RequestID is the request ID, if set by
+	// [github.com/go-chi/chi/v5/middleware.RequestID].
+	RequestID string `json:"request_id,omitempty"`
+	Method    string `json:"method"`
+	Path      string `json:"path"`
+	// Route is the route pattern that matched the request, if any.
+	Route        string `json:"route,omitempty"`
+	Status       int    `json:"status"`
+	BytesWritten int    `json:"bytes_written"`
+	// Latency is the time it took to handle the request, encoded as integer
+	// nanoseconds.
+	Latency   time.Duration `json:"latency_ns"`
+	Remote    string        `json:"remote"`
+	UserAgent string        `json:"user_agent,omitempty"`
+	Panicked  bool          `json:"panicked,omitempty"`
+	// Error is the message of the error attached using [Error], if any.
+	Error string `json:"error,omitempty"`
+}
+
+// A RecordSink receives a [RequestRecord] for every request completed by the
+// middleware, independent of the zap pipeline, e.g. to feed offline
+// analytics.
+//
+// WriteRecord is called concurrently, and must therefore be safe for
+// concurrent use.
+type RecordSink interface {
+	WriteRecord(rec RequestRecord) error
+}
+
+// WithRecordSink adds a [RecordSink] to the middleware.
+//
+// Like an [Observer], it is called synchronously for all requests, except
+// those excluded from logging, regardless of whether their completion entry
+// is actually written.
+//
+// If WriteRecord returns an error, it is logged at error level, using the
+// logger passed to [New].
+// Subsequent errors aren't logged, until a record was written successfully
+// again.
+func WithRecordSink(sink RecordSink) Option {
+	return func(c *config) {
+		c.recordSinks = append(c.recordSinks, sink)
+	}
+}
+
+// sinkObserver is an Observer writing the completed requests to a
+// RecordSink.
+type sinkObserver struct {
+	l    *zap.Logger
+	sink RecordSink
+
+	mu      sync.Mutex
+	failing bool
+}
+
+func (o *sinkObserver) ObserveCompletion(c Completion) {
+	rec := RequestRecord{
+		Time:         c.Start,
+		RequestID:    middleware.GetReqID(c.Request.Context()),
+		Method:       c.Request.Method,
+		Path:         c.Request.URL.Path,
+		Route:        c.Route,
+		Status:       c.Status,
+		BytesWritten: c.BytesWritten,
+		Latency:      c.Latency,
+		Remote:       c.Request.RemoteAddr,
+		UserAgent:    c.Request.UserAgent(),
+		Panicked:     c.Panicked,
+	}
+	if c.Err != nil {
+		rec.Error = c.Err.Error()
+	}
+
+	err := o.sink.WriteRecord(rec)
+
+	o.mu.Lock()
+	report := err != nil && !o.failing
+	o.failing = err != nil
+	o.mu.Unlock()
+
+	if report {
+		o.l.Error("unable to write request record", zap.Error(err))
+	}
+}
+
+// JSONLWriter is a [RecordSink] writing records as JSON Lines, i.e. one JSON
+// object per line.
+//
+// It must be created using [NewJSONLWriter] or [OpenJSONLFile].
+type JSONLWriter struct {
+	mu  sync.Mutex
+	w   io.Writer
+	enc *json.Encoder
+}
+
+var _ RecordSink = (*JSONLWriter)(nil)
+
+// NewJSONLWriter creates a new [JSONLWriter] writing to w.
+//
+// Each record is written using a single call to w.Write.
+func NewJSONLWriter(w io.Writer) *JSONLWriter {
+	return &JSONLWriter{w: w, enc: json.NewEncoder(w)}
+}
+
+// OpenJSONLFile creates a new [JSONLWriter] appending to the file with the
+// passed name, creating it, if it doesn't exist.
+//
+// The file must be closed using [JSONLWriter.Close].
+func OpenJSONLFile(name string) (*JSONLWriter, error) {
+	f, err := os.OpenFile(name, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o600)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewJSONLWriter(f), nil
+}
+
+// WriteRecord implements [RecordSink].
+func (w *JSONLWriter) WriteRecord(rec RequestRecord) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.enc.Encode(rec)
+}
+
+// Close closes the underlying writer, if it implements [io.Closer].
+func (w *JSONLWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if c, ok := w.w.(io.Closer); ok {
+		return c.Close()
+	}
+
+	return nil
+}
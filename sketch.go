@@ -0,0 +1,69 @@
+package chizap
+
+import (
+	"math"
+	"time"
+)
+
+const (
+	sketchMin     = time.Microsecond
+	sketchGrowth  = 1.1
+	sketchBuckets = 220 // 1µs * 1.1^219 ≈ 1,100s
+)
+
+// latencySketch estimates latency quantiles by counting latencies in
+// exponentially growing buckets, so that estimates have a relative error of
+// at most 10%, regardless of the number of observations.
+//
+// It is not safe for concurrent use.
+type latencySketch struct {
+	counts [sketchBuckets]uint64
+	total  uint64
+}
+
+func (s *latencySketch) add(d time.Duration) {
+	s.counts[sketchBucket(d)]++
+	s.total++
+}
+
+// quantile returns the estimated q-quantile, or 0 if there are no
+// observations.
+func (s *latencySketch) quantile(q float64) time.Duration {
+	if s.total == 0 {
+		return 0
+	}
+
+	rank := uint64(math.Ceil(q * float64(s.total)))
+	if rank == 0 {
+		rank = 1
+	}
+
+	var n uint64
+	for i, c := range s.counts {
+		n += c
+		if n >= rank {
+			return sketchUpperBound(i)
+		}
+	}
+
+	return sketchUpperBound(sketchBuckets - 1)
+}
+
+// sketchBucket returns the index of the bucket d falls into.
+func sketchBucket(d time.Duration) int {
+	if d <= sketchMin {
+		return 0
+	}
+
+	i := int(math.Ceil(math.Log(float64(d)/float64(sketchMin)) / math.Log(sketchGrowth)))
+	if i >= sketchBuckets {
+		return sketchBuckets - 1
+	}
+
+	return i
+}
+
+// sketchUpperBound returns the largest latency counted in bucket i.
+func sketchUpperBound(i int) time.Duration {
+	return time.Duration(float64(sketchMin) * math.Pow(sketchGrowth, float64(i)))
+}
@@ -0,0 +1,199 @@
+package chizap
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// SLO describes a service level objective monitored by an [SLOMonitor].
+type SLO struct {
+	// Availability is the targeted fraction of good requests, e.g. 0.999.
+	Availability float64
+	// LatencyThreshold, if set, is the latency above which requests are
+	// considered bad, in addition to those that failed with a 5xx status or
+	// panicked.
+	LatencyThreshold time.Duration
+	// Window is the duration of the sliding window, over which the burn rate
+	// is computed, e.g. 1h.
+	// If zero, DefaultSLOWindow is used.
+	Window time.Duration
+	// BurnRate is the burn rate at or above which the monitor alerts, e.g.
+	// 14.4, which consumes 2% of a 30 day error budget in 1h.
+	// If zero, DefaultSLOBurnRate is used.
+	BurnRate float64
+	// MinRequests is the minimum number of requests in the window required
+	// to alert, preventing alerts caused by single failures during low
+	// traffic.
+	MinRequests int
+}
+
+// Defaults used for the zero values of the respective [SLO] fields.
+const (
+	DefaultSLOWindow   = time.Hour
+	DefaultSLOBurnRate = 14.4
+)
+
+// SLOBurn holds the measurements of an SLO violation.
+type SLOBurn struct {
+	// BurnRate is the rate at which the error budget is consumed, i.e. the
+	// fraction of bad requests divided by the fraction of bad requests
+	// allowed by the SLO.
+	BurnRate float64
+	// Requests is the number of requests in the window, and Bad the number
+	// of bad ones among them.
+	Requests int
+	Bad      int
+}
+
+// SLOMonitor is an [Observer] that computes the burn rate of an [SLO] from
+// the completed requests, and alerts if it reaches the SLO's threshold.
+//
+// It must be created using [NewSLOMonitor].
+type SLOMonitor struct {
+	l      *zap.Logger
+	slo    SLO
+	onBurn func(SLOBurn)
+
+	mu       sync.Mutex
+	counter  windowCounter
+	alerting bool
+}
+
+var _ Observer = (*SLOMonitor)(nil)
+
+// NewSLOMonitor creates a new [SLOMonitor] monitoring slo.
+//
+// When the burn rate reaches slo.BurnRate, the monitor logs an error using
+// l, and calls onBurn, if it is not nil.
+// Afterwards, it doesn't alert again, until the burn rate dropped below the
+// threshold.
+//
+// onBurn is called synchronously, and should therefore return quickly.
+//
+// NewSLOMonitor panics, if slo.Availability is not between 0 and 1,
+// exclusive, if slo.Window is negative or too short to be divided into
+// slots, or if slo.BurnRate, slo.LatencyThreshold, or slo.MinRequests is
+// negative.
+func NewSLOMonitor(l *zap.Logger, slo SLO, onBurn func(SLOBurn)) *SLOMonitor {
+	if slo.Window == 0 {
+		slo.Window = DefaultSLOWindow
+	}
+	if slo.BurnRate == 0 {
+		slo.BurnRate = DefaultSLOBurnRate
+	}
+
+	switch {
+	case slo.Availability <= 0 || slo.Availability >= 1:
+		panic("chizap: SLO.Availability must be between 0 and 1, exclusive")
+	case !validWindow(slo.Window):
+		panic("chizap: SLO.Window must be at least " + (windowSlots * time.Nanosecond).String())
+	case slo.BurnRate < 0:
+		panic("chizap: SLO.BurnRate must not be negative")
+	case slo.LatencyThreshold < 0:
+		panic("chizap: SLO.LatencyThreshold must not be negative")
+	case slo.MinRequests < 0:
+		panic("chizap: SLO.MinRequests must not be negative")
+	}
+
+	return &SLOMonitor{l: l, slo: slo, onBurn: onBurn}
+}
+
+// ObserveCompletion implements [Observer].
+func (m *SLOMonitor) ObserveCompletion(c Completion) {
+	bad := c.Panicked || c.Status >= http.StatusInternalServerError ||
+		(m.slo.LatencyThreshold > 0 && c.Latency > m.slo.LatencyThreshold)
+
+	burn, alert := m.record(time.Now(), bad)
+	if !alert {
+		return
+	}
+
+	m.l.Error("SLO burn rate exceeded",
+		zap.Float64("burn_rate", burn.BurnRate),
+		zap.Float64("burn_rate_threshold", m.slo.BurnRate),
+		zap.Float64("availability_target", m.slo.Availability),
+		zap.Duration("window", m.slo.Window),
+		zap.Int("requests", burn.Requests),
+		zap.Int("bad_requests", burn.Bad),
+	)
+
+	if m.onBurn != nil {
+		m.onBurn(burn)
+	}
+}
+
+// record records a request completed at now, and reports whether the monitor
+// should alert.
+func (m *SLOMonitor) record(now time.Time, bad bool) (SLOBurn, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var burn SLOBurn
+	burn.Requests, burn.Bad = m.counter.add(now, m.slo.Window, bad)
+
+	if burn.Requests == 0 || burn.Requests < m.slo.MinRequests {
+		return burn, false
+	}
+
+	burn.BurnRate = float64(burn.Bad) / float64(burn.Requests) / (1 - m.slo.Availability)
+	if burn.BurnRate < m.slo.BurnRate {
+		m.alerting = false
+		return burn, false
+	}
+
+	if m.alerting {
+		return burn, false
+	}
+
+	m.alerting = true
+	return burn, true
+}
+
+const windowSlots = 10
+
+// windowCounter counts requests, and the bad ones among them, in a sliding
+// window, divided into windowSlots slots.
+type windowCounter struct {
+	slots [windowSlots]windowSlot
+}
+
+type windowSlot struct {
+	start    time.Time
+	requests int
+	bad      int
+}
+
+// validWindow reports whether window can be used with a windowCounter.
+func validWindow(window time.Duration) bool {
+	return window >= windowSlots
+}
+
+// add records a request completed at now, and returns the number of requests
+// and bad requests in the window ending at now.
+func (wc *windowCounter) add(now time.Time, window time.Duration, bad bool) (requests, badRequests int) {
+	slotDur := window / windowSlots
+	slotStart := now.Truncate(slotDur)
+
+	slot := &wc.slots[int(slotStart.UnixNano()/int64(slotDur))%windowSlots]
+	if !slot.start.Equal(slotStart) {
+		*slot = windowSlot{start: slotStart}
+	}
+
+	slot.requests++
+	if bad {
+		slot.bad++
+	}
+
+	cutoff := now.Add(-window)
+	for _, s := range wc.slots {
+		if s.start.After(cutoff) {
+			requests += s.requests
+			badRequests += s.bad
+		}
+	}
+
+	return requests, badRequests
+}
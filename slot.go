@@ -0,0 +1,49 @@
+package chizap
+
+import (
+	"net/http"
+
+	"go.uber.org/zap"
+)
+
+// WithDeploymentSlot adds a deployment_slot field to the completion entry,
+// e.g. canary, stable, blue, or green, so that error rates of deployment
+// slots can be compared directly on the logs.
+//
+// The slot is the one returned by f, or, if f is nil or returns an empty
+// string, the passed static slot, which is typically read from the
+// environment:
+//
+//	chizap.WithDeploymentSlot(os.Getenv("DEPLOYMENT_SLOT"), chizap.DeploymentSlotFromHeader("X-Canary"))
+//
+// If both are empty, the field is omitted.
+func WithDeploymentSlot(slot string, f func(r *http.Request) string) Option {
+	return func(c *config) {
+		c.deploymentSlot = slot
+		c.deploymentSlotFunc = f
+	}
+}
+
+// DeploymentSlotFromHeader returns a function that extracts the deployment
+// slot from the passed request header, e.g. one set by a load balancer that
+// routes a fraction of the traffic to a canary.
+func DeploymentSlotFromHeader(header string) func(r *http.Request) string {
+	return func(r *http.Request) string {
+		return r.Header.Get(header)
+	}
+}
+
+func (c *config) appendDeploymentSlotField(fields []zap.Field, r *http.Request) []zap.Field {
+	slot := c.deploymentSlot
+	if c.deploymentSlotFunc != nil {
+		if s := c.deploymentSlotFunc(r); s != "" {
+			slot = s
+		}
+	}
+
+	if slot == "" {
+		return fields
+	}
+
+	return append(fields, zap.String("deployment_slot", c.cleanField(slot)))
+}
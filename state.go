@@ -0,0 +1,134 @@
+package chizap
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"go.uber.org/zap"
+)
+
+type stateKey struct{}
+
+// state holds the per-request data shared between the middleware returned by
+// New and the cooperating middlewares of this package.
+type state struct {
+	// base is the logger passed to New, and contextFields returns the fields
+	// to be added to it, to create the logger saved in the request context.
+	//
+	// That logger is only created once it's first needed, so that requests
+	// that are neither logged nor call Get don't pay for building its
+	// fields.
+	base          *zap.Logger
+	contextFields func() []zap.Field
+	loggerOnce    sync.Once
+	l             *zap.Logger
+	fields        []zap.Field
+
+	cfg  *config
+	diag *diagnostics
+	// noRequestID is true, if the context logger has no request_id field,
+	// because the request had no request ID when it reached the middleware
+	// returned by New, or the field was removed using WithoutFields.
+	noRequestID bool
+
+	// uncompressed is the number of bytes written before compression, as
+	// measured by MeasureUncompressed.
+	uncompressed         int
+	measuredUncompressed bool
+
+	// panicked is set by Recoverer, if it recovered from a panic, or by the
+	// middleware, if it re-raises a panic not recovered by Recoverer.
+	panicked bool
+	// panicValue is the value the handler panicked with.
+	panicValue any
+	// panicStack is the stack trace of the panic re-raised by the middleware,
+	// or, if WithSingleEntryPanics is used, of the panic recovered by
+	// Recoverer.
+	panicStack []byte
+
+	// errs are the errors attached using Error, and err is their join, both
+	// guarded by valuesMu.
+	errs []error
+	err  error
+
+	// timedOut is true, if the request's context exceeded its deadline, and
+	// timeout is the timeout set by Timeout, if any.
+	timedOut bool
+	timeout  time.Duration
+
+	// queueStart and handlerStart are the times the request entered and left
+	// the throttle wrapped using Throttled.
+	queueStart   time.Time
+	handlerStart time.Time
+
+	// tunnel is the connection hijacked for a CONNECT tunnel, if any.
+	tunnel *tunnelConn
+
+	// handler is the name of the handler set by Handler.
+	handler string
+
+	// excluded is set by handlers whose requests must not be logged.
+	excluded bool
+
+	// panicBody records the request body for WithPanicBody.
+	panicBody *bodyRecorder
+	// reproBody records the request body for WithReproCurl.
+	reproBody *bodyRecorder
+	// bodyHash hashes the request body for WithBodyHash.
+	bodyHash *bodyHasher
+	// expectContinue tracks the reads of the body of requests with an
+	// Expect: 100-continue header for WithExpectContinue.
+	expectContinue *bodyTracker
+
+	// values holds the values stored using Set, and warnings the warnings
+	// buffered using Warnf.
+	valuesMu sync.Mutex
+	values   []namedValue
+	warnings []string
+}
+
+func newState(
+	r *http.Request, base *zap.Logger, cfg *config, diag *diagnostics, contextFields func() []zap.Field,
+) *state {
+	return &state{
+		base:          base,
+		contextFields: contextFields,
+		cfg:           cfg,
+		diag:          diag,
+		noRequestID:   !cfg.has(FieldRequestID) || middleware.GetReqID(r.Context()) == "",
+	}
+}
+
+// attach saves s in the context of r.
+func (s *state) attach(r *http.Request) {
+	*r = *r.WithContext(context.WithValue(r.Context(), stateKey{}, s))
+}
+
+// getState returns the state saved in the request context, or nil if there
+// is none.
+func getState(r *http.Request) *state {
+	s, _ := r.Context().Value(stateKey{}).(*state)
+	return s
+}
+
+// logger returns the logger saved in the request context, creating it if
+// necessary.
+func (s *state) logger() *zap.Logger {
+	s.initLogger()
+	return s.l
+}
+
+// initLogger creates the logger saved in the request context, if it hasn't
+// been created yet.
+func (s *state) initLogger() {
+	s.loggerOnce.Do(func() {
+		// Fall back to the base logger, should building the fields panic.
+		s.l = s.base
+
+		s.fields = s.contextFields()
+		s.l = s.base.With(s.fields...)
+	})
+}
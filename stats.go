@@ -0,0 +1,139 @@
+package chizap
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// StatsSnapshot is a snapshot of the statistics collected using [WithStats].
+type StatsSnapshot struct {
+	// Requests is the number of completed requests.
+	Requests uint64
+	// StatusClasses maps status classes, e.g. 2xx, to the number of requests
+	// completed with a status of that class.
+	StatusClasses map[string]uint64
+	// Panics is the number of requests whose handler panicked, regardless of
+	// whether the panic was recovered, and by which middleware.
+	// Panics with [net/http.ErrAbortHandler] are not counted.
+	Panics uint64
+	// RoutePanics maps route patterns to the number of requests to that route
+	// that panicked.
+	// Requests not matched by a route are counted using their templated path.
+	RoutePanics map[string]uint64
+
+	// LatencyP50, LatencyP95, and LatencyP99 are estimates of the respective
+	// latency percentiles, with a relative error of at most 10%.
+	LatencyP50 time.Duration
+	LatencyP95 time.Duration
+	LatencyP99 time.Duration
+}
+
+// defaultStats holds the statistics collected using WithStats.
+var defaultStats = &stats{statusClasses: make(map[string]uint64), routePanics: make(map[string]uint64)}
+
+type stats struct {
+	mu            sync.Mutex
+	requests      uint64
+	statusClasses map[string]uint64
+	panics        uint64
+	routePanics   map[string]uint64
+	latency       latencySketch
+}
+
+// WithStats collects in-process statistics about the completed requests,
+// which can be retrieved using [Stats] or [StatsHandler].
+// It is meant for services too small to run a metrics stack.
+//
+// Requests excluded from logging are not counted.
+// If multiple middlewares use WithStats, their statistics are combined.
+func WithStats() Option {
+	return WithObserver(defaultStats)
+}
+
+func (s *stats) ObserveCompletion(c Completion) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.requests++
+	s.statusClasses[statusClass(c.Status)]++
+	if c.Panicked {
+		s.panics++
+
+		route := c.Route
+		if route == "" {
+			route = templatePath(c.Request.URL.Path)
+		}
+		s.routePanics[route]++
+	}
+	s.latency.add(c.Latency)
+}
+
+// Stats returns a snapshot of the statistics collected using [WithStats].
+func Stats() StatsSnapshot {
+	s := defaultStats
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	classes := make(map[string]uint64, len(s.statusClasses))
+	for class, n := range s.statusClasses {
+		classes[class] = n
+	}
+
+	routePanics := make(map[string]uint64, len(s.routePanics))
+	for route, n := range s.routePanics {
+		routePanics[route] = n
+	}
+
+	return StatsSnapshot{
+		Requests:      s.requests,
+		StatusClasses: classes,
+		Panics:        s.panics,
+		RoutePanics:   routePanics,
+		LatencyP50:    s.latency.quantile(0.5),
+		LatencyP95:    s.latency.quantile(0.95),
+		LatencyP99:    s.latency.quantile(0.99),
+	}
+}
+
+// StatsHandler returns a handler that responds with the statistics
+// collected using [WithStats], encoded as JSON, with latencies in
+// milliseconds:
+//
+//	{
+//	  "requests": 1024,
+//	  "status_classes": {"2xx": 1000, "4xx": 20, "5xx": 4},
+//	  "panics": 1,
+//	  "route_panics": {"/users/{id}": 1},
+//	  "latency_p50_ms": 2.1,
+//	  "latency_p95_ms": 14.5,
+//	  "latency_p99_ms": 80.2
+//	}
+func StatsHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		snap := Stats()
+
+		ms := func(d time.Duration) float64 { return float64(d) / float64(time.Millisecond) }
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(struct {
+			Requests      uint64            `json:"requests"`
+			StatusClasses map[string]uint64 `json:"status_classes"`
+			Panics        uint64            `json:"panics"`
+			RoutePanics   map[string]uint64 `json:"route_panics"`
+			LatencyP50    float64           `json:"latency_p50_ms"`
+			LatencyP95    float64           `json:"latency_p95_ms"`
+			LatencyP99    float64           `json:"latency_p99_ms"`
+		}{
+			Requests:      snap.Requests,
+			StatusClasses: snap.StatusClasses,
+			Panics:        snap.Panics,
+			RoutePanics:   snap.RoutePanics,
+			LatencyP50:    ms(snap.LatencyP50),
+			LatencyP95:    ms(snap.LatencyP95),
+			LatencyP99:    ms(snap.LatencyP99),
+		})
+	})
+}
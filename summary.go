@@ -0,0 +1,138 @@
+package chizap
+
+import (
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// WithAccessSummary logs a summary of the requests completed during each
+// interval at info level, giving small deployments trend visibility straight
+// from the logs.
+//
+// Each summary holds the following fields:
+//   - requests: the number of requests completed during the interval
+//   - error_rate: the fraction of those requests that failed with a 5xx
+//     status or panicked
+//   - p95_latency: an estimate of the 95th latency percentile, with a
+//     relative error of at most 10%
+//   - slowest_routes: the up to 5 routes with the highest average latency,
+//     as an array of objects holding the route, its number of requests, and
+//     its avg_latency
+//
+// Requests excluded from logging are not summarized, and intervals without
+// requests are skipped.
+//
+// The summaries are logged by a goroutine that is started when the
+// middleware is created.
+// To log the summary of the current interval when shutting down, register
+// the middleware with a [Closer] using [WithCloser].
+// Otherwise, the goroutine runs for the remainder of the program.
+func WithAccessSummary(interval time.Duration) Option {
+	return func(c *config) {
+		c.accessSummaryInterval = interval
+	}
+}
+
+const slowestRoutes = 5
+
+type (
+	accessSummary struct {
+		l *zap.Logger
+
+		mu       sync.Mutex
+		requests int
+		errors   int
+		latency  latencySketch
+		routes   map[string]*routeStats
+	}
+
+	routeStats struct {
+		route    string
+		requests int
+		latency  time.Duration
+	}
+)
+
+func newAccessSummary(l *zap.Logger, interval time.Duration, cl *Closer) *accessSummary {
+	s := &accessSummary{l: l, routes: make(map[string]*routeStats)}
+	flushPeriodically(l, interval, s.flush, cl)
+	return s
+}
+
+func (s *accessSummary) ObserveCompletion(c Completion) {
+	route := c.Route
+	if route == "" {
+		route = templatePath(c.Request.URL.Path)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.requests++
+	if c.Panicked || c.Status >= http.StatusInternalServerError {
+		s.errors++
+	}
+	s.latency.add(c.Latency)
+
+	rs, ok := s.routes[route]
+	if !ok {
+		rs = &routeStats{route: route}
+		s.routes[route] = rs
+	}
+	rs.requests++
+	rs.latency += c.Latency
+}
+
+func (s *accessSummary) flush() {
+	s.mu.Lock()
+	requests, errors := s.requests, s.errors
+	p95 := s.latency.quantile(0.95)
+	routes := make([]*routeStats, 0, len(s.routes))
+	for _, rs := range s.routes {
+		routes = append(routes, rs)
+	}
+
+	s.requests, s.errors = 0, 0
+	s.latency = latencySketch{}
+	s.routes = make(map[string]*routeStats, len(s.routes))
+	s.mu.Unlock()
+
+	if requests == 0 {
+		return
+	}
+
+	sort.Slice(routes, func(i, j int) bool { return routes[i].avg() > routes[j].avg() })
+	if len(routes) > slowestRoutes {
+		routes = routes[:slowestRoutes]
+	}
+
+	s.l.Info("access summary",
+		zap.Int("requests", requests),
+		zap.Float64("error_rate", float64(errors)/float64(requests)),
+		zap.Duration("p95_latency", p95),
+		zap.Array("slowest_routes", zapcore.ArrayMarshalerFunc(func(enc zapcore.ArrayEncoder) error {
+			for _, rs := range routes {
+				if err := enc.AppendObject(rs); err != nil {
+					return err
+				}
+			}
+			return nil
+		})),
+	)
+}
+
+func (rs *routeStats) avg() time.Duration {
+	return rs.latency / time.Duration(rs.requests)
+}
+
+func (rs *routeStats) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	enc.AddString("route", rs.route)
+	enc.AddInt("requests", rs.requests)
+	enc.AddDuration("avg_latency", rs.avg())
+	return nil
+}
@@ -0,0 +1,113 @@
+package chizap
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+// DefaultMaxHeaderSize is the header size used by
+// [WithSuspiciousRequestFlags], if none is passed.
+const DefaultMaxHeaderSize = 8 << 10
+
+// WithSuspiciousRequestFlags analyzes requests using cheap heuristics, and
+// adds the following fields to the completion entries of suspicious
+// requests, so that security dashboards can be built on the logs without a
+// WAF:
+//   - path_traversal_attempt: the path or query contains ../ or ..\, also
+//     if percent-encoded
+//   - sql_injection_pattern: the query contains typical SQL injection
+//     patterns, e.g. ' or 1=1 or union select
+//   - xss_pattern: the query contains typical cross-site scripting
+//     patterns, e.g. <script or javascript:
+//   - oversized_header: a header value exceeds maxHeaderSize bytes, or
+//     [DefaultMaxHeaderSize], if maxHeaderSize is 0
+//
+// Each field is only added if it is true.
+// The heuristics are neither exhaustive nor free of false positives, and are
+// not a replacement for proper input validation.
+func WithSuspiciousRequestFlags(maxHeaderSize int) Option {
+	if maxHeaderSize <= 0 {
+		maxHeaderSize = DefaultMaxHeaderSize
+	}
+
+	return func(c *config) {
+		c.suspicious = true
+		c.maxHeaderSize = maxHeaderSize
+	}
+}
+
+var (
+	sqlInjectionPatterns = []string{
+		// Comments are only matched after a quote, as /* and */ alone are
+		// common in benign queries, e.g. accept=*/* or glob filters.
+		"' or ", "'or'", "\" or ", " or 1=1", "union select", "union all select", "';--", "'--", "'/*",
+		"; drop ", "sleep(", "benchmark(", "waitfor delay", "information_schema",
+	}
+	xssPatterns = []string{"<script", "javascript:", "onerror=", "onload=", "<iframe", "<svg", "document.cookie"}
+)
+
+func appendSuspiciousFields(fields []zap.Field, r *http.Request, maxHeaderSize int) []zap.Field {
+	rawPath := r.URL.EscapedPath()
+	query := decodeAll(r.URL.RawQuery)
+
+	if isTraversal(decodeAll(rawPath)) || isTraversal(query) {
+		fields = append(fields, zap.Bool("path_traversal_attempt", true))
+	}
+
+	if containsAny(query, sqlInjectionPatterns) {
+		fields = append(fields, zap.Bool("sql_injection_pattern", true))
+	}
+	if containsAny(query, xssPatterns) {
+		fields = append(fields, zap.Bool("xss_pattern", true))
+	}
+
+	if hasOversizedHeader(r.Header, maxHeaderSize) {
+		fields = append(fields, zap.Bool("oversized_header", true))
+	}
+
+	return fields
+}
+
+// decodeAll lowercases s and repeatedly percent-decodes it, to uncover
+// double-encoded payloads.
+func decodeAll(s string) string {
+	s = strings.ToLower(s)
+	for i := 0; i < 3 && strings.Contains(s, "%"); i++ {
+		dec, err := url.QueryUnescape(s)
+		if err != nil || dec == s {
+			break
+		}
+		s = strings.ToLower(dec)
+	}
+
+	return s
+}
+
+func isTraversal(s string) bool {
+	return strings.Contains(s, "../") || strings.Contains(s, `..\`)
+}
+
+func containsAny(s string, patterns []string) bool {
+	for _, p := range patterns {
+		if strings.Contains(s, p) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func hasOversizedHeader(h http.Header, limit int) bool {
+	for _, vals := range h {
+		for _, v := range vals {
+			if len(v) > limit {
+				return true
+			}
+		}
+	}
+
+	return false
+}
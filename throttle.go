@@ -0,0 +1,55 @@
+package chizap
+
+import (
+	"net/http"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Throttled wraps throttle, e.g.
+// [github.com/go-chi/chi/v5/middleware.Throttle], and measures how long
+// requests wait in its queue, so that capacity issues can be distinguished
+// from slow handlers.
+//
+// It must be mounted after the middleware returned by [New]:
+//
+//	r.Use(chizap.Logger(l))
+//	r.Use(chizap.Throttled(middleware.Throttle(100)))
+//
+// If used, the completion entry will hold the following additional fields,
+// encoded as configured using [WithLatencyUnit]:
+//   - queue_time: the time the request waited for throttle to let it through,
+//     or, if it was rejected, until it was rejected
+//   - handler_time: the time it took to handle the request after throttle
+//     let it through, omitted if it was rejected
+func Throttled(throttle func(http.Handler) http.Handler) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		h := throttle(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if s := getState(r); s != nil {
+				s.handlerStart = time.Now()
+			}
+
+			next.ServeHTTP(w, r)
+		}))
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if s := getState(r); s != nil {
+				s.queueStart = time.Now()
+			}
+
+			h.ServeHTTP(w, r)
+		})
+	}
+}
+
+func appendThrottleFields(fields []zap.Field, s *state, end time.Time, u LatencyUnit) []zap.Field {
+	if s.handlerStart.IsZero() {
+		return append(fields, durationField("queue_time", end.Sub(s.queueStart), u))
+	}
+
+	return append(fields,
+		durationField("queue_time", s.handlerStart.Sub(s.queueStart), u),
+		durationField("handler_time", end.Sub(s.handlerStart), u),
+	)
+}
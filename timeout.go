@@ -0,0 +1,50 @@
+package chizap
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// Timeout is a drop-in replacement for
+// [github.com/go-chi/chi/v5/middleware.Timeout], that additionally reports
+// timed out requests to the middleware returned by [New].
+//
+// It must be mounted after the middleware returned by [New].
+// If the request times out, the outcome of the completion entry is timeout
+// instead of server_error, and it holds the additional field timeout, which
+// is the passed timeout.
+//
+// Requests whose context exceeded its deadline before reaching the
+// middleware returned by [New] are reported as timed out even without
+// Timeout, albeit without the timeout field.
+func Timeout(timeout time.Duration) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		h := middleware.Timeout(timeout)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			defer func() {
+				if isDeadlineExceeded(r) {
+					if s := getState(r); s != nil {
+						s.timedOut = true
+					}
+				}
+			}()
+
+			next.ServeHTTP(w, r)
+		}))
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if s := getState(r); s != nil {
+				s.timeout = timeout
+			}
+
+			h.ServeHTTP(w, r)
+		})
+	}
+}
+
+func isDeadlineExceeded(r *http.Request) bool {
+	return errors.Is(r.Context().Err(), context.DeadlineExceeded)
+}
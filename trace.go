@@ -0,0 +1,46 @@
+package chizap
+
+import (
+	"net/http"
+	"runtime/trace"
+
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// WithTrace creates a [runtime/trace] task and region for each request,
+// named after its route, and logs the request's ID to the task.
+// This allows correlating the output of go tool trace with the logged
+// requests.
+//
+// The route is determined in the same way as for [WithPprofLabels].
+func WithTrace() Option {
+	return func(c *config) {
+		c.trace = true
+	}
+}
+
+// traceTask wraps next, so that it handles requests inside a trace task and
+// region.
+func (c *config) traceTask(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !trace.IsEnabled() {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		route := c.routeName(r)
+
+		ctx, task := trace.NewTask(r.Context(), r.Method+" "+route)
+		defer task.End()
+
+		if id := middleware.GetReqID(ctx); id != "" {
+			trace.Log(ctx, "request_id", id)
+		}
+
+		// See labelProfiles for why we keep the same *http.Request.
+		*r = *r.WithContext(ctx)
+		defer trace.StartRegion(ctx, route).End()
+
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,83 @@
+package chizap
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"go.uber.org/zap"
+)
+
+// Transport wraps base, so that outgoing requests made with the context of
+// a request handled by the middleware returned by [New] are correlated with
+// it.
+// If base is nil, [http.DefaultTransport] is used.
+//
+// If the context holds a request ID, Transport sets the X-Request-Id header of
+// the outgoing request to it, unless already set.
+// Additionally, it logs the outgoing call using the logger saved in the
+// request context, i.e. with the same request_id.
+// The entry holds an egress object with the following fields:
+//   - method: the method of the outgoing request
+//   - url: the URL of the outgoing request, without its query
+//   - status: the status code of the response, omitted on error
+//   - latency: the time until the response headers were received, encoded
+//     as configured using [WithLatencyUnit]
+//   - error: the error returned by base, if any
+//
+// The entry is logged at info level, or, if base returned an error, at
+// error level.
+//
+// Usage:
+//
+//	client := &http.Client{Transport: chizap.Transport(nil)}
+//	req, _ := http.NewRequestWithContext(r.Context(), http.MethodGet, url, nil)
+//	resp, err := client.Do(req)
+func Transport(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	return &transport{base: base}
+}
+
+type transport struct {
+	base http.RoundTripper
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if id := middleware.GetReqID(req.Context()); id != "" && req.Header.Get(middleware.RequestIDHeader) == "" {
+		// RoundTrippers must not modify the passed request.
+		req = req.Clone(req.Context())
+		req.Header.Set(middleware.RequestIDHeader, id)
+	}
+
+	s, _ := req.Context().Value(stateKey{}).(*state)
+	if s == nil {
+		return t.base.RoundTrip(req)
+	}
+
+	start := time.Now()
+	resp, err := t.base.RoundTrip(req)
+	lat := time.Since(start)
+
+	u := *req.URL
+	u.RawQuery = ""
+	u.User = nil
+
+	fields := []zap.Field{
+		zap.Namespace("egress"),
+		zap.String("method", req.Method),
+		zap.String("url", u.String()),
+	}
+
+	if err != nil {
+		fields = append(fields, durationField("latency", lat, s.cfg.latencyUnit), zap.Error(err))
+		s.logger().Error("egress "+req.Method+" "+u.Host, fields...)
+		return resp, err
+	}
+
+	fields = append(fields, zap.Int("status", resp.StatusCode), durationField("latency", lat, s.cfg.latencyUnit))
+	s.logger().Info("egress "+req.Method+" "+u.Host, fields...)
+	return resp, nil
+}
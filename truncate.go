@@ -0,0 +1,59 @@
+package chizap
+
+import "unicode/utf8"
+
+// truncatedMarker is appended to values truncated because of
+// WithMaxFieldLength.
+const truncatedMarker = "…(truncated)"
+
+// WithMaxFieldLength truncates the user-controlled fields, i.e. the user
+// agent, referer, query, header values, and captured bodies, to at most n
+// bytes, appending a "…(truncated)" marker to truncated values.
+//
+// This protects log pipelines from requests carrying oversized values, e.g.
+// multi-megabyte headers.
+//
+// Truncation is applied after sanitization, and never splits a UTF-8
+// encoded rune.
+// The captured bodies are those logged through [WithReplayCapture] and
+// [WithReproCurl].
+func WithMaxFieldLength(n int) Option {
+	return func(c *config) {
+		c.maxFieldLength = n
+	}
+}
+
+// cleanField sanitizes s using the configured sanitizer, and truncates it to
+// the maximum field length.
+func (c *config) cleanField(s string) string {
+	return truncate(c.clean(s), c.maxFieldLength)
+}
+
+// truncate truncates s to at most n bytes, and appends truncatedMarker, if s
+// is longer than n bytes.
+// If n is not positive, s is returned unchanged.
+func truncate(s string, n int) string {
+	if n <= 0 || len(s) <= n {
+		return s
+	}
+
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+
+	return s[:n] + truncatedMarker
+}
+
+// truncateBytes is like truncate, but operates on byte slices.
+// It reports whether b was truncated.
+func truncateBytes(b []byte, n int) ([]byte, bool) {
+	if n <= 0 || len(b) <= n {
+		return b, false
+	}
+
+	for n > 0 && !utf8.RuneStart(b[n]) {
+		n--
+	}
+
+	return append(b[:n:n], truncatedMarker...), true
+}
@@ -0,0 +1,125 @@
+package chizap
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"go.uber.org/zap"
+)
+
+// WithTunnels logs the lifecycle of tunnels established by hijacking the
+// connection of a CONNECT request, e.g. by a forward proxy.
+//
+// The completion entry of such a request holds the additional field
+// tunnel_established set to true, and its latency is the time it took to
+// establish the tunnel.
+// Once the hijacked connection is closed, another entry is logged at info
+// level using the logger saved in the request context, holding the
+// following fields:
+//   - tunnel_duration: the time from establishing until closing the tunnel,
+//     encoded as configured using [WithLatencyUnit]
+//   - bytes_received: the number of bytes read from the client
+//   - bytes_sent: the number of bytes written to the client
+//
+// The byte counts only include data relayed through the net.Conn returned by
+// Hijack, not through its bufio.ReadWriter.
+func WithTunnels() Option {
+	return func(c *config) {
+		c.tunnels = true
+	}
+}
+
+// tunnelWriter wraps the Hijack method of a WrapResponseWriter, to track the
+// hijacked connection.
+//
+// It must be wrapped using preserveCapabilities.
+type tunnelWriter struct {
+	middleware.WrapResponseWriter
+	s *state
+	r *http.Request
+}
+
+func (w *tunnelWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := w.WrapResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("chizap: response writer does not implement http.Hijacker")
+	}
+
+	conn, brw, err := h.Hijack()
+	if err != nil {
+		return conn, brw, err
+	}
+
+	tc := &tunnelConn{
+		Conn:  conn,
+		s:     w.s,
+		msg:   "CONNECT " + w.s.cfg.clean(messagePath(w.r)) + " tunnel closed",
+		start: time.Now(),
+	}
+	w.s.tunnel = tc
+	return tc, brw, nil
+}
+
+func (w *tunnelWriter) Flush() {
+	if f, ok := w.WrapResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (w *tunnelWriter) ReadFrom(r io.Reader) (int64, error) {
+	return readFrom(w.WrapResponseWriter, r)
+}
+
+func (w *tunnelWriter) Push(target string, opts *http.PushOptions) error {
+	if p, ok := w.WrapResponseWriter.(http.Pusher); ok {
+		return p.Push(target, opts)
+	}
+
+	return http.ErrNotSupported
+}
+
+// tunnelConn counts the bytes relayed through a tunnel, and logs them once
+// it is closed.
+type tunnelConn struct {
+	net.Conn
+	s     *state
+	msg   string
+	start time.Time
+
+	received  atomic.Int64
+	sent      atomic.Int64
+	closeOnce sync.Once
+}
+
+func (c *tunnelConn) Read(p []byte) (int, error) {
+	n, err := c.Conn.Read(p)
+	c.received.Add(int64(n))
+	return n, err
+}
+
+func (c *tunnelConn) Write(p []byte) (int, error) {
+	n, err := c.Conn.Write(p)
+	c.sent.Add(int64(n))
+	return n, err
+}
+
+func (c *tunnelConn) Close() error {
+	err := c.Conn.Close()
+
+	c.closeOnce.Do(func() {
+		c.s.logger().Info(c.msg,
+			durationField("tunnel_duration", time.Since(c.start), c.s.cfg.latencyUnit),
+			zap.Int64("bytes_received", c.received.Load()),
+			zap.Int64("bytes_sent", c.sent.Load()),
+		)
+	})
+
+	return err
+}
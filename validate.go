@@ -0,0 +1,94 @@
+package chizap
+
+import (
+	"errors"
+	"fmt"
+
+	"go.uber.org/zap"
+)
+
+// validateArgs checks the arguments passed to New for values that would make
+// the middleware fail.
+// It replaces a nil logger with a no-op one, removes nil options, and returns
+// an error describing each problem, or nil, if all arguments are valid.
+func validateArgs(l *zap.Logger, opts []Option) (*zap.Logger, []Option, error) {
+	var errs []error
+
+	if l == nil {
+		l = zap.NewNop()
+		errs = append(errs, errors.New("nil logger passed to New, falling back to a no-op logger"))
+	}
+
+	valid := opts[:0:0]
+	for i, opt := range opts {
+		if opt == nil {
+			errs = append(errs, fmt.Errorf("option %d passed to New is nil, ignoring it", i))
+			continue
+		}
+
+		valid = append(valid, opt)
+	}
+
+	return l, valid, errors.Join(errs...)
+}
+
+// validate checks c for options that would make the middleware fail when
+// handling requests, disables them, and returns an error describing each, or
+// nil, if all options are valid.
+func (c *config) validate() error {
+	var errs []error
+
+	if c.replayLogger != nil && c.replayCapture == nil {
+		c.replayLogger = nil
+		errs = append(errs, errors.New("WithReplayCapture: capture is nil, disabling replay capture"))
+	}
+
+	if c.asyncQueueSize < 0 {
+		c.asyncQueueSize = 0
+		errs = append(errs, errors.New("WithAsync: queueSize is negative, logging synchronously"))
+	}
+
+	var nilObservers int
+	c.observers, nilObservers = withoutNil(c.observers, func(o Observer) bool { return o == nil })
+	var nilIPObservers int
+	c.ipObservers, nilIPObservers = withoutNil(c.ipObservers, func(o IPObserver) bool { return o == nil })
+	var nilHooks int
+	c.completionHooks, nilHooks = withoutNil(c.completionHooks, func(h CompletionHook) bool { return h == nil })
+	var nilPanicHooks int
+	c.panicHooks, nilPanicHooks = withoutNil(c.panicHooks, func(h PanicHook) bool { return h == nil })
+	var nilErrorHooks int
+	c.errorHooks, nilErrorHooks = withoutNil(c.errorHooks, func(h ErrorHook) bool { return h == nil })
+	var nilSinks int
+	c.recordSinks, nilSinks = withoutNil(c.recordSinks, func(s RecordSink) bool { return s == nil })
+
+	if n := nilObservers + nilIPObservers + nilHooks + nilPanicHooks + nilErrorHooks + nilSinks; n > 0 {
+		errs = append(errs, fmt.Errorf("%d nil observers, hooks, or sinks passed, ignoring them", n))
+	}
+
+	return errors.Join(errs...)
+}
+
+// withoutNil removes all elements of s for which isNil returns true, and
+// returns the number of removed elements.
+func withoutNil[T any](s []T, isNil func(T) bool) ([]T, int) {
+	valid := s[:0]
+	for _, e := range s {
+		if !isNil(e) {
+			valid = append(valid, e)
+		}
+	}
+
+	return valid, len(s) - len(valid)
+}
+
+// reportInvalid reports the problems found by validateArgs and validate
+// using l, or, if l is a no-op logger, e.g. because no logger was passed to
+// New, by writing them to stderr.
+func reportInvalid(l *zap.Logger, err error) {
+	if l.Core().Enabled(zap.WarnLevel) {
+		l.Warn("chizap: invalid arguments passed to New", zap.Error(err))
+		return
+	}
+
+	fmt.Fprintf(stderr, "chizap: invalid arguments passed to New: %v\n", err)
+}
@@ -0,0 +1,79 @@
+package chizap
+
+import (
+	"net/http"
+
+	"go.uber.org/zap"
+)
+
+// namedValue is a value set using Set.
+type namedValue struct {
+	name  string
+	value any
+}
+
+// Set stores value under name for the remainder of the request, and adds it
+// as a field with that name to the completion entry.
+// It can be retrieved by handlers and middlewares further down the chain
+// using [Value].
+//
+// If Set is called multiple times with the same name, the last value wins,
+// but the field keeps the position of the first call.
+//
+// If the request wasn't handled by the middleware returned by [New], Set is
+// a no-op.
+func Set[T any](r *http.Request, name string, value T) {
+	s := getState(r)
+	if s == nil {
+		return
+	}
+
+	s.valuesMu.Lock()
+	defer s.valuesMu.Unlock()
+
+	for i, v := range s.values {
+		if v.name == name {
+			s.values[i].value = value
+			return
+		}
+	}
+
+	s.values = append(s.values, namedValue{name: name, value: value})
+}
+
+// Value returns the value stored under name using [Set].
+//
+// If there is no such value, or it is not of type T, Value returns the zero
+// value of T and false.
+func Value[T any](r *http.Request, name string) (T, bool) {
+	var zero T
+
+	s := getState(r)
+	if s == nil {
+		return zero, false
+	}
+
+	s.valuesMu.Lock()
+	defer s.valuesMu.Unlock()
+
+	for _, v := range s.values {
+		if v.name == name {
+			t, ok := v.value.(T)
+			return t, ok
+		}
+	}
+
+	return zero, false
+}
+
+// appendValueFields appends the values stored using Set to fields.
+func (s *state) appendValueFields(fields []zap.Field) []zap.Field {
+	s.valuesMu.Lock()
+	defer s.valuesMu.Unlock()
+
+	for _, v := range s.values {
+		fields = append(fields, zap.Any(v.name, v.value))
+	}
+
+	return fields
+}
@@ -0,0 +1,43 @@
+package chizap
+
+import (
+	"fmt"
+	"net/http"
+
+	"go.uber.org/zap"
+)
+
+// Warnf formats a warning according to format and buffers it, to be logged as
+// part of the warnings array of the completion entry.
+//
+// Use Warnf for lightweight warnings, e.g. a cache miss or a fallback being
+// used, that don't warrant an entry of their own.
+// Warnf doesn't affect the level of the completion entry.
+//
+// If the request wasn't handled by the middleware returned by [New], Warnf is
+// a no-op.
+func Warnf(r *http.Request, format string, args ...any) {
+	s := getState(r)
+	if s == nil {
+		return
+	}
+
+	msg := fmt.Sprintf(format, args...)
+
+	s.valuesMu.Lock()
+	defer s.valuesMu.Unlock()
+
+	s.warnings = append(s.warnings, msg)
+}
+
+// appendWarningsField appends the warnings buffered using Warnf to fields.
+func (s *state) appendWarningsField(fields []zap.Field) []zap.Field {
+	s.valuesMu.Lock()
+	defer s.valuesMu.Unlock()
+
+	if len(s.warnings) == 0 {
+		return fields
+	}
+
+	return append(fields, zap.Strings("warnings", s.warnings))
+}
@@ -0,0 +1,45 @@
+package chizap
+
+// DefaultField identifies one of the default fields of the context logger,
+// as described in [New].
+type DefaultField uint8
+
+const (
+	// FieldRequestID is the request_id field.
+	FieldRequestID DefaultField = 1 << iota
+	// FieldProto is the proto field.
+	FieldProto
+	// FieldMethod is the method field.
+	FieldMethod
+	// FieldPath is the path field.
+	FieldPath
+	// FieldQuery is the query field, or the query_params field, if
+	// [WithQueryParams] is used.
+	FieldQuery
+	// FieldRemote is the remote field.
+	FieldRemote
+	// FieldUserAgent is the user_agent field.
+	FieldUserAgent
+	// FieldReferer is the referer field.
+	FieldReferer
+)
+
+// WithoutFields removes the passed default fields from the context logger,
+// and therefore from every entry logged by the middleware, e.g. to reduce
+// log size for deployments that never query them.
+//
+// Entries logged for panics by [Recoverer] and [Go] still hold the
+// request_id field, so that they can be matched to user reports.
+func WithoutFields(fields ...DefaultField) Option {
+	return func(c *config) {
+		for _, f := range fields {
+			c.withoutFields |= f
+		}
+	}
+}
+
+// has reports whether the default field f wasn't removed using
+// WithoutFields.
+func (c *config) has(f DefaultField) bool {
+	return c.withoutFields&f == 0
+}
@@ -0,0 +1,97 @@
+package chizap
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"net/http"
+
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// capableWriter is a WrapResponseWriter implementing all optional interfaces
+// of an http.ResponseWriter, like the writers wrapping the one passed to New.
+type capableWriter interface {
+	middleware.WrapResponseWriter
+	http.Flusher
+	http.Hijacker
+	http.Pusher
+	io.ReaderFrom
+}
+
+// preserveCapabilities returns a WrapResponseWriter calling w, which only
+// implements those of http.Flusher, http.Hijacker, http.Pusher, and
+// io.ReaderFrom that are implemented by inner, so that wrapping inner in w
+// doesn't change the interfaces handlers can assert.
+//
+// inner must be created using middleware.NewWrapResponseWriter, or be the
+// result of a previous call to preserveCapabilities.
+func preserveCapabilities(w capableWriter, inner middleware.WrapResponseWriter) middleware.WrapResponseWriter {
+	_, fl := inner.(http.Flusher)
+	_, hj := inner.(http.Hijacker)
+	_, ps := inner.(http.Pusher)
+	_, rf := inner.(io.ReaderFrom)
+
+	bw := basicWriter{WrapResponseWriter: w, w: w}
+
+	switch {
+	case fl && hj && rf:
+		return &httpFancyWriter{bw}
+	case fl && hj:
+		return &flushHijackWriter{bw}
+	case fl && ps:
+		return &http2FancyWriter{bw}
+	case fl:
+		return &flushWriter{bw}
+	case hj:
+		return &hijackWriter{bw}
+	default:
+		return &bw
+	}
+}
+
+// basicWriter exposes only the methods of a WrapResponseWriter.
+type basicWriter struct {
+	middleware.WrapResponseWriter
+	w capableWriter
+}
+
+type flushWriter struct{ basicWriter }
+
+func (w *flushWriter) Flush() { w.w.Flush() }
+
+type hijackWriter struct{ basicWriter }
+
+func (w *hijackWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) { return w.w.Hijack() }
+
+type flushHijackWriter struct{ basicWriter }
+
+func (w *flushHijackWriter) Flush() { w.w.Flush() }
+
+func (w *flushHijackWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) { return w.w.Hijack() }
+
+type httpFancyWriter struct{ basicWriter }
+
+func (w *httpFancyWriter) Flush() { w.w.Flush() }
+
+func (w *httpFancyWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) { return w.w.Hijack() }
+
+func (w *httpFancyWriter) ReadFrom(r io.Reader) (int64, error) { return w.w.ReadFrom(r) }
+
+type http2FancyWriter struct{ basicWriter }
+
+func (w *http2FancyWriter) Flush() { w.w.Flush() }
+
+func (w *http2FancyWriter) Push(target string, opts *http.PushOptions) error {
+	return w.w.Push(target, opts)
+}
+
+// readFrom copies r to w using the io.ReaderFrom implementation of w, if any,
+// or io.Copy otherwise.
+func readFrom(w http.ResponseWriter, r io.Reader) (int64, error) {
+	if rf, ok := w.(io.ReaderFrom); ok {
+		return rf.ReadFrom(r)
+	}
+
+	return io.Copy(struct{ io.Writer }{w}, r)
+}